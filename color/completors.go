@@ -7,10 +7,10 @@ import (
 type fetcher struct{}
 
 // TODO: add existing stuff in here so don't display already present format.
-func (f *fetcher) Fetch(value *commands.Value, args, flags map[string]*commands.Value) *commands.Completion {
+func (f *fetcher) Fetch(value *commands.Value, args, flags map[string]*commands.Value) (*commands.Completion, error) {
 	return &commands.Completion{
 		Suggestions: Attributes(),
-	}
+	}, nil
 }
 
 func Completor() *commands.Completor {
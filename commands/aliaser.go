@@ -16,9 +16,9 @@ const (
 
 type Aliaser interface {
 	// Validate verifies the given value.
-	Validate(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) bool
+	Validate(alias string, value *Value, args, flags map[string]*Value) error
 	// Transform transforms the validated value.
-	Transform(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) (*Value, bool)
+	Transform(alias string, value *Value, args, flags map[string]*Value) (*Value, error)
 	// Arg is the Arg type for the alias.
 	Arg() Arg
 }
@@ -58,22 +58,20 @@ func TestFileAliaser(fakeStat func(s string) (os.FileInfo, error), fakeAbs func(
 	}
 }
 
-func (fa *fileAliaser) Validate(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) bool {
+func (fa *fileAliaser) Validate(alias string, value *Value, args, flags map[string]*Value) error {
 	if _, err := fa.osStat(value.String()); err != nil {
-		cos.Stderr("file does not exist: %v", err)
-		return false
+		return fmt.Errorf("file does not exist: %v", err)
 	}
-	return true
+	return nil
 }
 
-func (fa *fileAliaser) Transform(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) (*Value, bool) {
+func (fa *fileAliaser) Transform(alias string, value *Value, args, flags map[string]*Value) (*Value, error) {
 	absPath, err := fa.absPath(value.String())
 	if err != nil {
-		cos.Stderr("failed to get absolute file path for file %q: %v", value.String(), err)
-		return nil, false
+		return nil, fmt.Errorf("failed to get absolute file path for file %q: %v", value.String(), err)
 	}
 
-	return StringValue(absPath), true
+	return StringValue(absPath), nil
 }
 
 func (*fileAliaser) Arg() Arg {
@@ -87,14 +85,14 @@ type AliasFetcher struct {
 	ac *aliasCommand
 }
 
-func (af *AliasFetcher) Fetch(value *Value, args, flags map[string]*Value) *Completion {
+func (af *AliasFetcher) Fetch(value *Value, args, flags map[string]*Value) (*Completion, error) {
 	suggestions := make([]string, 0, len(af.ac.Aliases()))
 	for k := range af.ac.Aliases() {
 		suggestions = append(suggestions, k)
 	}
 	return &Completion{
 		Suggestions: suggestions,
-	}
+	}, nil
 }
 
 func AliasSubcommands(cli AliasCLI, aliaser Aliaser, name string) map[string]Command {
@@ -179,43 +177,41 @@ func (ac *aliasCommand) MarkChanged() {
 }
 
 // GetAlias fetches an existing alias, if it exists.
-func (ac *aliasCommand) GetAlias(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+func (ac *aliasCommand) GetAlias(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
 	alias := args[AliasArg].String()
 	f, ok := ac.GetCLIAlias(alias)
 	if !ok {
-		cos.Stderr("Alias %q does not exist", alias)
-		return nil, false
+		return nil, fmt.Errorf("Alias %q does not exist", alias)
 	}
 	cos.Stdout("%s: %s", alias, f.Str())
-	return nil, true
+	return nil, nil
 }
 
 // AddAlias adds an alias.
-func (ac *aliasCommand) AddAlias(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+func (ac *aliasCommand) AddAlias(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
 	alias := args[AliasArg].String()
 	value := args[ac.aliaser.Arg().Name()]
 
 	if f, ok := ac.GetCLIAlias(alias); ok {
-		cos.Stderr("alias already defined: (%s: %s)", alias, f.Str())
-		return nil, false
+		return nil, fmt.Errorf("alias already defined: (%s: %s)", alias, f.Str())
 	}
 
 	// Verify the alias.
-	if !ac.aliaser.Validate(cos, alias, value, args, flags) {
-		return nil, false
+	if err := ac.aliaser.Validate(alias, value, args, flags); err != nil {
+		return nil, validationError(ac.aliaser.Arg().Name(), err)
 	}
 
-	var ok bool
-	if value, ok = ac.aliaser.Transform(cos, alias, value, args, flags); !ok {
-		return nil, false
+	value, err := ac.aliaser.Transform(alias, value, args, flags)
+	if err != nil {
+		return nil, err
 	}
 
 	ac.SetCLIAlias(alias, value)
-	return nil, true
+	return nil, nil
 }
 
 // DeleteAliases deletes an existing alias.
-func (ac *aliasCommand) DeleteAliases(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+func (ac *aliasCommand) DeleteAliases(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
 	for _, alias := range args[AliasArg].StringList() {
 		if _, ok := ac.GetCLIAlias(alias); !ok {
 			cos.Stderr("alias %q does not exist", alias)
@@ -223,15 +219,15 @@ func (ac *aliasCommand) DeleteAliases(cos CommandOS, args, flags map[string]*Val
 			ac.DeleteCLIAlias(alias)
 		}
 	}
-	return nil, true
+	return nil, nil
 }
 
 // ListAliases removes an existing alias.
-func (ac *aliasCommand) ListAliases(cos CommandOS, _, _ map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+func (ac *aliasCommand) ListAliases(cos CommandOS, _, _ map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
 	for _, aliasStr := range ac.listAliases() {
-		cos.Stdout(aliasStr)
+		cos.Stdout("%s", aliasStr)
 	}
-	return nil, true
+	return nil, nil
 }
 
 func (ac *aliasCommand) listAliases() []string {
@@ -250,17 +246,16 @@ func (ac *aliasCommand) listAliases() []string {
 }
 
 // SearchAliases searches through existing aliases.
-func (ac *aliasCommand) SearchAliases(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+func (ac *aliasCommand) SearchAliases(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
 	searchRegex, err := regexp.Compile(args[RegexpArg].String())
 	if err != nil {
-		cos.Stderr("Invalid regexp: %v", err)
-		return nil, false
+		return nil, validationError(RegexpArg, fmt.Errorf("Invalid regexp: %v", err))
 	}
 
 	for _, aliasStr := range ac.listAliases() {
 		if searchRegex.MatchString(aliasStr) {
-			cos.Stdout(aliasStr)
+			cos.Stdout("%s", aliasStr)
 		}
 	}
-	return nil, true
+	return nil, nil
 }
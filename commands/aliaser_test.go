@@ -104,7 +104,10 @@ func TestAliaserAutocomplete(t *testing.T) {
 			if test.ac.Aliaser == nil {
 				test.ac.Aliaser = &testAliaser{}
 			}
-			suggestions := Autocomplete(test.ac.Command(), test.args, -1)
+			suggestions, err := Autocomplete(test.ac.Command(), test.args, -1)
+			if err != nil {
+				t.Fatalf("Complete(%v) returned error: %v", test.args, err)
+			}
 			if diff := cmp.Diff(test.want, suggestions); diff != "" {
 				t.Errorf("Complete(%v) produced diff (-want, +got):\n%s", test.args, diff)
 			}
@@ -114,22 +117,22 @@ func TestAliaserAutocomplete(t *testing.T) {
 
 type testAliaser struct {
 	arg       Arg
-	validate  func(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) bool
-	transform func(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) (*Value, bool)
+	validate  func(alias string, value *Value, args, flags map[string]*Value) error
+	transform func(alias string, value *Value, args, flags map[string]*Value) (*Value, error)
 }
 
-func (ta *testAliaser) Validate(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) bool {
+func (ta *testAliaser) Validate(alias string, value *Value, args, flags map[string]*Value) error {
 	if ta.validate == nil {
-		return true
+		return nil
 	}
-	return ta.validate(cos, alias, value, args, flags)
+	return ta.validate(alias, value, args, flags)
 }
 
-func (ta *testAliaser) Transform(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) (*Value, bool) {
+func (ta *testAliaser) Transform(alias string, value *Value, args, flags map[string]*Value) (*Value, error) {
 	if ta.transform == nil {
-		return value, true
+		return value, nil
 	}
-	return ta.transform(cos, alias, value, args, flags)
+	return ta.transform(alias, value, args, flags)
 }
 
 func (ta *testAliaser) Arg() Arg {
@@ -245,9 +248,8 @@ func TestAliasCommandExecution(t *testing.T) {
 			ac: &basicCLI{
 				Aliaser: &testAliaser{
 					arg: StringArg("str", true, nil),
-					validate: func(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) bool {
-						cos.Stderr("bad news bears")
-						return false
+					validate: func(alias string, value *Value, args, flags map[string]*Value) error {
+						return fmt.Errorf("bad news bears")
 					},
 				},
 			},
@@ -261,17 +263,13 @@ func TestAliasCommandExecution(t *testing.T) {
 			ac: &basicCLI{
 				Aliaser: &testAliaser{
 					arg: StringArg("str", true, nil),
-					validate: func(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) bool {
-						cos.Stdout("good news tigers")
-						return true
+					validate: func(alias string, value *Value, args, flags map[string]*Value) error {
+						return nil
 					},
 				},
 			},
 			args:   []string{"a", "salt", "NaCl"},
 			wantOK: true,
-			wantStdout: []string{
-				"good news tigers",
-			},
 			want: &basicCLI{
 				AllAliases: map[string]map[string]*Value{
 					"base": {
@@ -285,9 +283,8 @@ func TestAliasCommandExecution(t *testing.T) {
 			ac: &basicCLI{
 				Aliaser: &testAliaser{
 					arg: StringArg("str", true, nil),
-					transform: func(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) (*Value, bool) {
-						cos.Stderr("bad news lions")
-						return nil, false
+					transform: func(alias string, value *Value, args, flags map[string]*Value) (*Value, error) {
+						return nil, fmt.Errorf("bad news lions")
 					},
 				},
 			},
@@ -301,8 +298,8 @@ func TestAliasCommandExecution(t *testing.T) {
 			ac: &basicCLI{
 				Aliaser: &testAliaser{
 					arg: StringArg("str", true, nil),
-					transform: func(cos CommandOS, alias string, value *Value, args, flags map[string]*Value) (*Value, bool) {
-						return StringListValue("Na", "Cl"), true
+					transform: func(alias string, value *Value, args, flags map[string]*Value) (*Value, error) {
+						return StringListValue("Na", "Cl"), nil
 					},
 				},
 			},
@@ -440,6 +437,21 @@ func TestAliasCommandExecution(t *testing.T) {
 				"salt: Na, Cl",
 			},
 		},
+		{
+			name: "ListAliases prints percent signs verbatim",
+			ac: &basicCLI{
+				AllAliases: map[string]map[string]*Value{
+					"base": {
+						"progress": StringValue("100%done"),
+					},
+				},
+			},
+			args:   []string{"l"},
+			wantOK: true,
+			wantStdout: []string{
+				"progress: 100%done",
+			},
+		},
 		// SearchAlias tests.
 		{
 			name: "SearchAlias requires a regex",
@@ -477,6 +489,22 @@ func TestAliasCommandExecution(t *testing.T) {
 				"pepper: sneezy",
 			},
 		},
+		{
+			name: "SearchAlias prints percent signs verbatim",
+			ac: &basicCLI{
+				AllAliases: map[string]map[string]*Value{
+					"base": {
+						"progress": StringValue("100%done"),
+						"pepper":   StringValue("sneezy"),
+					},
+				},
+			},
+			args:   []string{"s", "%"},
+			wantOK: true,
+			wantStdout: []string{
+				"progress: 100%done",
+			},
+		},
 		// FileAliaser tests (only need to test AddAlias).
 		{
 			name: "FileAliaser fails if stat error in validate",
@@ -519,8 +547,8 @@ func TestAliasCommandExecution(t *testing.T) {
 				test.ac.Aliaser = &testAliaser{}
 			}
 			tcos := &TestCommandOS{}
-			got, ok := Execute(tcos, test.ac.Command(), test.args, nil)
-			if ok != test.wantOK {
+			got, err := Execute(tcos, test.ac.Command(), test.args, nil)
+			if ok := err == nil; ok != test.wantOK {
 				t.Fatalf("commands.Execute(%v) returned %v for ok; want %v", test.args, ok, test.wantOK)
 			}
 			if diff := cmp.Diff(test.wantResp, got); diff != "" {
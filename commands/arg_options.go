@@ -5,9 +5,143 @@ import (
 	"strings"
 )
 
+// setting is an ArgOpt that configures an argument instead of validating its
+// value.
+type setting struct {
+	apply func(*argSettings)
+}
+
+func (s *setting) ValueType() ValueType {
+	return UnspecifiedValueType
+}
+
+func (s *setting) Validate(*Value) error {
+	return nil
+}
+
+// argSettings is the configuration of an argument set by setting options.
+type argSettings struct {
+	defaultValue *Value
+	envVar       string
+	configKey    string
+	repeatable   bool
+	description  string
+}
+
+func newArgSettings(opts []ArgOpt) *argSettings {
+	as := &argSettings{}
+	for _, opt := range opts {
+		if s, ok := opt.(*setting); ok {
+			s.apply(as)
+		}
+	}
+	return as
+}
+
+// Default sets the value that is used when the argument isn't provided. The
+// default value's Provided method returns false. Defining a required argument
+// with a default value, or an argument with a default value of the wrong type
+// (or that fails the argument's other options), panics.
+func Default(v *Value) ArgOpt {
+	return &setting{
+		apply: func(as *argSettings) { as.defaultValue = v },
+	}
+}
+
+// EnvVar sets the environment variable that supplies a flag's value when the
+// flag isn't provided on the command line. List flag values are separated by
+// commas.
+func EnvVar(name string) ArgOpt {
+	return &setting{
+		apply: func(as *argSettings) { as.envVar = name },
+	}
+}
+
+// ConfigKey sets the key in the CLI's config file (see Option.ConfigFile)
+// that supplies a flag's value when the flag isn't provided on the command
+// line or by its environment variable (see EnvVar).
+func ConfigKey(key string) ArgOpt {
+	return &setting{
+		apply: func(as *argSettings) { as.configKey = key },
+	}
+}
+
+// Repeatable lets a list flag be provided multiple times. The values of each
+// occurrence are appended to the flag's list (e.g. "--tag a --tag b c" sets
+// the flag to [a, b, c]). Otherwise, only the last occurrence is used.
+func Repeatable() ArgOpt {
+	return &setting{
+		apply: func(as *argSettings) { as.repeatable = true },
+	}
+}
+
+// Description sets the description of an argument or flag. It is displayed
+// in help output, generated docs and (for flags) completion suggestions in
+// shells that support descriptions.
+func Description(desc string) ArgOpt {
+	return &setting{
+		apply: func(as *argSettings) { as.description = desc },
+	}
+}
+
+// validate runs all of the validating options on v.
+func validate(name string, vt ValueType, v *Value, opts []ArgOpt) error {
+	for _, opt := range opts {
+		if _, ok := opt.(*setting); ok {
+			continue
+		}
+
+		if vt != opt.ValueType() {
+			return fmt.Errorf("option can only be bound to arguments with type %v", opt.ValueType())
+		}
+
+		if err := opt.Validate(v); err != nil {
+			return validationError(name, fmt.Errorf("validation failed: %v", err))
+		}
+	}
+	return nil
+}
+
+// checkDefault panics if the default value in opts isn't valid for the
+// argument. Required arguments can't have a default value since it would never
+// be used.
+func checkDefault(name string, vt ValueType, required bool, opts []ArgOpt) {
+	dv := newArgSettings(opts).defaultValue
+	if dv == nil {
+		return
+	}
+	if required {
+		panic(fmt.Sprintf("default value for %q can't be used since the argument is required", name))
+	}
+	if !dv.IsType(vt) {
+		panic(fmt.Sprintf("default value for %q has type %s; expected %s", name, typeToString[dv.type_], typeToString[vt]))
+	}
+	if err := validate(name, vt, dv, opts); err != nil {
+		panic(fmt.Sprintf("invalid default value for %q: %v", name, err))
+	}
+}
+
+// describer is an ArgOpt that describes the values it accepts.
+type describer interface {
+	Description() string
+}
+
+// validatorDescriptions returns the descriptions of the validating options in
+// opts (see ArgUsage.Validators).
+func validatorDescriptions(opts []ArgOpt) []string {
+	var descs []string
+	for _, opt := range opts {
+		if d, ok := opt.(describer); ok && d.Description() != "" {
+			descs = append(descs, d.Description())
+		}
+	}
+	return descs
+}
+
 type option struct {
-	vt       ValueType
-	validate func(*Value) error
+	vt          ValueType
+	validate    func(*Value) error
+	description string
 }
 
 func (o *option) ValueType() ValueType {
@@ -18,8 +152,17 @@ func (o *option) Validate(v *Value) error {
 	return o.validate(v)
 }
 
+// Description returns a description of the values that the option accepts.
+func (o *option) Description() string {
+	return o.description
+}
+
 // String options
 func StringOption(f func(string) bool, err error) ArgOpt {
+	return stringOption("", f, err)
+}
+
+func stringOption(description string, f func(string) bool, err error) ArgOpt {
 	validator := func(v *Value) error {
 		if !f(v.String()) {
 			return err
@@ -27,20 +170,23 @@ func StringOption(f func(string) bool, err error) ArgOpt {
 		return nil
 	}
 	return &option{
-		vt:       StringType,
-		validate: validator,
+		vt:          StringType,
+		validate:    validator,
+		description: description,
 	}
 }
 
 func Contains(s string) ArgOpt {
-	return StringOption(
+	return stringOption(
+		fmt.Sprintf("must contain %q", s),
 		func(vs string) bool { return strings.Contains(vs, s) },
 		fmt.Errorf("[Contains] value doesn't contain substring %q", s),
 	)
 }
 
 func MinLength(length int) ArgOpt {
-	return StringOption(
+	return stringOption(
+		fmt.Sprintf("must be at least %d characters", length),
 		func(vs string) bool { return len(vs) >= length },
 		fmt.Errorf("[MinLength] value must be at least %d characters", length),
 	)
@@ -48,6 +194,10 @@ func MinLength(length int) ArgOpt {
 
 // Int options
 func IntOption(f func(int) bool, err error) ArgOpt {
+	return intOption("", f, err)
+}
+
+func intOption(description string, f func(int) bool, err error) ArgOpt {
 	validator := func(v *Value) error {
 		if !f(v.Int()) {
 			return err
@@ -55,69 +205,79 @@ func IntOption(f func(int) bool, err error) ArgOpt {
 		return nil
 	}
 	return &option{
-		vt:       IntType,
-		validate: validator,
+		vt:          IntType,
+		validate:    validator,
+		description: description,
 	}
 }
 
 func IntEQ(i int) ArgOpt {
-	return IntOption(
+	return intOption(
+		fmt.Sprintf("must equal %d", i),
 		func(vi int) bool { return vi == i },
 		fmt.Errorf("[IntEQ] value isn't equal to %d", i),
 	)
 }
 
 func IntNE(i int) ArgOpt {
-	return IntOption(
+	return intOption(
+		fmt.Sprintf("must not equal %d", i),
 		func(vi int) bool { return vi != i },
 		fmt.Errorf("[IntNE] value isn't not equal to %d", i),
 	)
 }
 
 func IntLT(i int) ArgOpt {
-	return IntOption(
+	return intOption(
+		fmt.Sprintf("must be less than %d", i),
 		func(vi int) bool { return vi < i },
 		fmt.Errorf("[IntLT] value isn't less than %d", i),
 	)
 }
 
 func IntLTE(i int) ArgOpt {
-	return IntOption(
+	return intOption(
+		fmt.Sprintf("must be less than or equal to %d", i),
 		func(vi int) bool { return vi <= i },
 		fmt.Errorf("[IntLTE] value isn't less than or equal to %d", i),
 	)
 }
 
 func IntGT(i int) ArgOpt {
-	return IntOption(
+	return intOption(
+		fmt.Sprintf("must be greater than %d", i),
 		func(vi int) bool { return vi > i },
 		fmt.Errorf("[IntGT] value isn't greater than %d", i),
 	)
 }
 
 func IntGTE(i int) ArgOpt {
-	return IntOption(
+	return intOption(
+		fmt.Sprintf("must be greater than or equal to %d", i),
 		func(vi int) bool { return vi >= i },
 		fmt.Errorf("[IntGTE] value isn't greater than or equal to %d", i),
 	)
 }
 
 func IntPositive() ArgOpt {
-	return IntOption(
+	return intOption(
+		"must be positive",
 		func(vi int) bool { return vi > 0 },
 		fmt.Errorf("[IntPositive] value isn't positive"),
 	)
 }
 
 func IntNonNegative() ArgOpt {
-	return IntOption(
+	return intOption(
+		"must be non-negative",
 		func(vi int) bool { return vi >= 0 },
 		fmt.Errorf("[IntNonNegative] value isn't non-negative"),
 	)
 }
 
 func IntNegative() ArgOpt {
-	return IntOption(
+	return intOption(
+		"must be negative",
 		func(vi int) bool { return vi < 0 },
 		fmt.Errorf("[IntNegative] value isn't negative"),
 	)
@@ -125,6 +285,10 @@ func IntNegative() ArgOpt {
 
 // Float options
 func FloatOption(f func(float64) bool, err error) ArgOpt {
+	return floatOption("", f, err)
+}
+
+func floatOption(description string, f func(float64) bool, err error) ArgOpt {
 	validator := func(v *Value) error {
 		if !f(v.Float()) {
 			return err
@@ -132,69 +296,79 @@ func FloatOption(f func(float64) bool, err error) ArgOpt {
 		return nil
 	}
 	return &option{
-		vt:       FloatType,
-		validate: validator,
+		vt:          FloatType,
+		validate:    validator,
+		description: description,
 	}
 }
 
 func FloatEQ(f float64) ArgOpt {
-	return FloatOption(
+	return floatOption(
+		fmt.Sprintf("must equal %v", f),
 		func(vf float64) bool { return vf == f },
 		fmt.Errorf("[FloatEQ] value isn't equal to %0.2f", f),
 	)
 }
 
 func FloatNE(f float64) ArgOpt {
-	return FloatOption(
+	return floatOption(
+		fmt.Sprintf("must not equal %v", f),
 		func(vf float64) bool { return vf != f },
 		fmt.Errorf("[FloatNE] value isn't not equal to %0.2f", f),
 	)
 }
 
 func FloatLT(f float64) ArgOpt {
-	return FloatOption(
+	return floatOption(
+		fmt.Sprintf("must be less than %v", f),
 		func(vf float64) bool { return vf < f },
 		fmt.Errorf("[FloatLT] value isn't less than %0.2f", f),
 	)
 }
 
 func FloatLTE(f float64) ArgOpt {
-	return FloatOption(
+	return floatOption(
+		fmt.Sprintf("must be less than or equal to %v", f),
 		func(vf float64) bool { return vf <= f },
 		fmt.Errorf("[FloatLTE] value isn't less than or equal to %0.2f", f),
 	)
 }
 
 func FloatGT(f float64) ArgOpt {
-	return FloatOption(
+	return floatOption(
+		fmt.Sprintf("must be greater than %v", f),
 		func(vf float64) bool { return vf > f },
 		fmt.Errorf("[FloatGT] value isn't greater than %0.2f", f),
 	)
 }
 
 func FloatGTE(f float64) ArgOpt {
-	return FloatOption(
+	return floatOption(
+		fmt.Sprintf("must be greater than or equal to %v", f),
 		func(vf float64) bool { return vf >= f },
 		fmt.Errorf("[FloatGTE] value isn't greater than or equal to %0.2f", f),
 	)
 }
 
 func FloatPositive() ArgOpt {
-	return FloatOption(
+	return floatOption(
+		"must be positive",
 		func(vi float64) bool { return vi > 0 },
 		fmt.Errorf("[FloatPositive] value isn't positive"),
 	)
 }
 
 func FloatNonNegative() ArgOpt {
-	return FloatOption(
+	return floatOption(
+		"must be non-negative",
 		func(vi float64) bool { return vi >= 0 },
 		fmt.Errorf("[FloatNonNegative] value isn't non-negative"),
 	)
 }
 
 func FloatNegative() ArgOpt {
-	return FloatOption(
+	return floatOption(
+		"must be negative",
 		func(vi float64) bool { return vi < 0 },
 		fmt.Errorf("[FloatNegative] value isn't negative"),
 	)
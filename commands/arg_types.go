@@ -30,7 +30,7 @@ func cp(s []string) []string {
 }
 
 func StringArg(name string, required bool, completor *Completor, opts ...ArgOpt) Arg {
-	return &singleArgProcessor{
+	return newSingleArgProcessor(&singleArgProcessor{
 		name:      name,
 		completor: completor,
 		opts:      opts,
@@ -39,11 +39,11 @@ func StringArg(name string, required bool, completor *Completor, opts ...ArgOpt)
 		transform: func(s string) (*Value, error) {
 			return StringValue(s), nil
 		},
-	}
+	})
 }
 
 func IntArg(name string, required bool, completor *Completor, opts ...ArgOpt) Arg {
-	return &singleArgProcessor{
+	return newSingleArgProcessor(&singleArgProcessor{
 		name:      name,
 		optional:  !required,
 		completor: completor,
@@ -56,11 +56,11 @@ func IntArg(name string, required bool, completor *Completor, opts ...ArgOpt) Ar
 			}
 			return IntValue(i), err
 		},
-	}
+	})
 }
 
 func FloatArg(name string, required bool, completor *Completor, opts ...ArgOpt) Arg {
-	return &singleArgProcessor{
+	return newSingleArgProcessor(&singleArgProcessor{
 		name:      name,
 		completor: completor,
 		opts:      opts,
@@ -73,15 +73,15 @@ func FloatArg(name string, required bool, completor *Completor, opts ...ArgOpt)
 			}
 			return FloatValue(f), err
 		},
-	}
+	})
 }
 
 func BoolArg(name string, required bool, opts ...ArgOpt) Arg {
-	return &singleArgProcessor{
+	return newSingleArgProcessor(&singleArgProcessor{
 		name:      name,
 		completor: BoolCompletor(),
 		opts:      opts,
-		vt:        FloatType,
+		vt:        BoolType,
 		optional:  !required,
 		transform: func(s string) (*Value, error) {
 			b, err := strconv.ParseBool(s)
@@ -90,11 +90,11 @@ func BoolArg(name string, required bool, opts ...ArgOpt) Arg {
 			}
 			return BoolValue(b), err
 		},
-	}
+	})
 }
 
 func StringListArg(name string, minN, optionalN int, completor *Completor, opts ...ArgOpt) Arg {
-	return &listArgProcessor{
+	return newListArgProcessor(&listArgProcessor{
 		name:      name,
 		minN:      minN,
 		optionalN: optionalN,
@@ -102,11 +102,11 @@ func StringListArg(name string, minN, optionalN int, completor *Completor, opts
 		opts:      opts,
 		vt:        StringListType,
 		transform: func(s []string) (*Value, error) { return StringListValue(s...), nil },
-	}
+	})
 }
 
 func IntListArg(name string, minN, optionalN int, completor *Completor, opts ...ArgOpt) Arg {
-	return &listArgProcessor{
+	return newListArgProcessor(&listArgProcessor{
 		name:      name,
 		minN:      minN,
 		optionalN: optionalN,
@@ -114,7 +114,7 @@ func IntListArg(name string, minN, optionalN int, completor *Completor, opts ...
 		opts:      opts,
 		vt:        IntListType,
 		transform: intListTransform,
-	}
+	})
 }
 
 func intListTransform(sl []string) (*Value, error) {
@@ -133,7 +133,7 @@ func intListTransform(sl []string) (*Value, error) {
 }
 
 func FloatListArg(name string, minN, optionalN int, completor *Completor, opts ...ArgOpt) Arg {
-	return &listArgProcessor{
+	return newListArgProcessor(&listArgProcessor{
 		name:      name,
 		minN:      minN,
 		optionalN: optionalN,
@@ -141,7 +141,7 @@ func FloatListArg(name string, minN, optionalN int, completor *Completor, opts .
 		opts:      opts,
 		vt:        FloatListType,
 		transform: floatListTransform,
-	}
+	})
 }
 
 func floatListTransform(sl []string) (*Value, error) {
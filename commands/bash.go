@@ -0,0 +1,158 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ExecutableFileEnv is the environment variable that the wrapper generated
+	// by BashWrapper uses to tell the binary where to write executables.
+	ExecutableFileEnv = "COMMANDS_EXECUTABLE_FILE"
+)
+
+var (
+	invalidFunctionChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+)
+
+// quoteBash returns s quoted so bash interprets it as a single literal word.
+func quoteBash(s string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `'\''`))
+}
+
+// bashCommand returns a bash command that runs the given words literally.
+func bashCommand(words []string) string {
+	quoted := make([]string, 0, len(words))
+	for _, w := range words {
+		quoted = append(quoted, quoteBash(w))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// functionName returns a shell function name for the given CLI and purpose.
+func functionName(cli, purpose string) string {
+	return fmt.Sprintf("_%s_%s", invalidFunctionChars.ReplaceAllString(cli, "_"), purpose)
+}
+
+// BashCompletion returns a bash script that sets up autocompletion for cli
+// and can be sourced from a .bashrc file.
+//
+// The generated function fetches suggestions by running
+//
+//	binary autocomplete CLI COMP_CWORD WORDS...
+//
+// where WORDS are the words of the command line (excluding the CLI name) up
+// to the cursor. binary should pass everything after the CLI name to
+// RunAutocomplete, which prints one suggestion per line. Every line is used
+// as-is, so the " " suggestion added for Completion.DontComplete and the
+// suffixed duplicates returned by FileFetcher keep working: both ensure bash
+// never inserts a shared prefix that isn't a valid completion.
+func BashCompletion(binary, cli string) string {
+	fn := functionName(cli, "completion")
+	return strings.Join([]string{
+		fmt.Sprintf("# Autocompletion for %s.", cli),
+		fmt.Sprintf("function %s {", fn),
+		fmt.Sprintf(`  mapfile -t COMPREPLY < <(%s autocomplete %s "$COMP_CWORD" "${COMP_WORDS[@]:1:$COMP_CWORD}")`, quoteBash(binary), quoteBash(cli)),
+		"}",
+		fmt.Sprintf("complete -F %s %s", fn, quoteBash(cli)),
+		"",
+	}, "\n")
+}
+
+// RunAutocomplete handles a completion request from the script generated by
+// BashCompletion. args are the cursor index followed by the words of the
+// command line (excluding the CLI name).
+func RunAutocomplete(cos CommandOS, c Command, args []string) error {
+	return runAutocomplete(cos, c, args, Autocomplete)
+}
+
+// runAutocomplete parses a completion request and prints the suggestions
+// produced by the shell-specific autocomplete function. Invalid requests are
+// written to stderr, but completion errors are only written to the trace file
+// (see TraceFileEnv) so they don't clutter the user's command line.
+func runAutocomplete(cos CommandOS, c Command, args []string, autocomplete func(Command, []string, int) ([]string, error)) error {
+	if len(args) == 0 {
+		err := usageErrorf("no cursor index provided")
+		cos.Stderr("%s", err)
+		return err
+	}
+
+	cursorIdx, err := strconv.Atoi(args[0])
+	if err != nil {
+		err = usageErrorf("cursor index should be an integer: %v", err)
+		cos.Stderr("%s", err)
+		return err
+	}
+
+	defer startTrace()()
+	tracef("completing %q with cursor index %d", args[1:], cursorIdx)
+	suggestions, err := autocomplete(c, args[1:], cursorIdx)
+	if err != nil {
+		tracef("completion failed: %v", err)
+		return err
+	}
+	tracef("returning suggestions %q", suggestions)
+
+	for _, s := range suggestions {
+		cos.Stdout("%s", s)
+	}
+	return nil
+}
+
+// ExecutableScript returns a bash script that runs each of the given
+// executables in order. Every word is quoted so it's passed to bash literally
+// (including spaces, quotes, $ and newlines).
+func ExecutableScript(executables ...[]string) string {
+	var lines []string
+	for _, e := range executables {
+		if len(e) == 0 {
+			continue
+		}
+		lines = append(lines, bashCommand(e)+"\n")
+	}
+	return strings.Join(lines, "")
+}
+
+// WriteResponse writes the script returned by resp.Script() to file.
+func WriteResponse(file string, resp *ExecutorResponse) error {
+	script, err := resp.Script()
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(file, []byte(script), 0644); err != nil {
+		return fmt.Errorf("failed to write executable file: %v", err)
+	}
+	return nil
+}
+
+// BashWrapper returns a bash script that defines cli as an alias for a
+// function that runs
+//
+//	binary execute CLI ARGS...
+//
+// and then sources the file that binary wrote its response to (see
+// WriteResponse). The file is passed to binary in the ExecutableFileEnv
+// environment variable. Since the file is sourced by the calling shell,
+// the response's actions can change its directory or environment.
+func BashWrapper(binary, cli string) string {
+	fn := functionName(cli, "execute")
+	return strings.Join([]string{
+		fmt.Sprintf("# Wrapper for %s.", cli),
+		fmt.Sprintf("function %s {", fn),
+		`  local tmpFile="$(mktemp)"`,
+		fmt.Sprintf(`  %s="$tmpFile" %s execute %s "$@"`, ExecutableFileEnv, quoteBash(binary), quoteBash(cli)),
+		"  local errorCode=$?",
+		`  if [ "$errorCode" -eq 0 ] && [ -s "$tmpFile" ]; then`,
+		`    source "$tmpFile"`,
+		"    errorCode=$?",
+		"  fi",
+		`  rm -f "$tmpFile"`,
+		"  return $errorCode",
+		"}",
+		fmt.Sprintf("alias %s=%s", quoteBash(cli), fn),
+		"",
+	}, "\n")
+}
@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBashCompletion(t *testing.T) {
+	want := strings.Join([]string{
+		"# Autocompletion for my-cli.",
+		"function _my_cli_completion {",
+		`  mapfile -t COMPREPLY < <('/usr/bin/it'\''s' autocomplete 'my-cli' "$COMP_CWORD" "${COMP_WORDS[@]:1:$COMP_CWORD}")`,
+		"}",
+		"complete -F _my_cli_completion 'my-cli'",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, BashCompletion("/usr/bin/it's", "my-cli")); diff != "" {
+		t.Errorf("BashCompletion() returned diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestBashCompletionScript(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	for _, test := range []struct {
+		name      string
+		words     []string
+		cword     int
+		binaryOut string
+		want      []string
+	}{
+		{
+			name:      "passes cursor and words to binary",
+			words:     []string{"mycli", "basic", `fo\ o`},
+			cword:     2,
+			binaryOut: `printf '%s\n' "$@"`,
+			want:      []string{"autocomplete", "mycli", "2", "basic", `fo\ o`},
+		},
+		{
+			name:      "ignores words after the cursor",
+			words:     []string{"mycli", "basic", "", "after"},
+			cword:     2,
+			binaryOut: `printf '%s\n' "$@"`,
+			want:      []string{"autocomplete", "mycli", "2", "basic", ""},
+		},
+		{
+			name:      "keeps DontComplete suggestion",
+			words:     []string{"mycli", ""},
+			cword:     1,
+			binaryOut: `printf '%s\n' "one" "two*" " "`,
+			want:      []string{"one", "two*", " "},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "bash_completion_test")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			binary := filepath.Join(dir, "binary")
+			if err := ioutil.WriteFile(binary, []byte("#!/bin/bash\n"+test.binaryOut+"\n"), 0755); err != nil {
+				t.Fatalf("failed to write binary: %v", err)
+			}
+
+			quoted := make([]string, 0, len(test.words))
+			for _, w := range test.words {
+				quoted = append(quoted, quoteBash(w))
+			}
+			script := strings.Join([]string{
+				BashCompletion(binary, "mycli"),
+				"COMP_WORDS=(" + strings.Join(quoted, " ") + ")",
+				fmt.Sprintf("COMP_CWORD=%d", test.cword),
+				"_mycli_completion",
+				`printf '%s|' "${COMPREPLY[@]}"`,
+			}, "\n")
+
+			out, err := exec.Command("bash", "-c", script).Output()
+			if err != nil {
+				t.Fatalf("failed to run completion script: %v", err)
+			}
+			got := strings.Split(strings.TrimSuffix(string(out), "|"), "|")
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("completion script produced COMPREPLY diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunAutocomplete(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		args       []string
+		want       bool
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:       "requires cursor index",
+			wantStderr: []string{"no cursor index provided"},
+		},
+		{
+			name:       "requires integer cursor index",
+			args:       []string{"one", "b"},
+			wantStderr: []string{`cursor index should be an integer: strconv.Atoi: parsing "one": invalid syntax`},
+		},
+		{
+			name:       "prints errors verbatim",
+			args:       []string{"50%d"},
+			wantStderr: []string{`cursor index should be an integer: strconv.Atoi: parsing "50%d": invalid syntax`},
+		},
+		{
+			name:       "prints suggestions",
+			args:       []string{"1", "b"},
+			want:       true,
+			wantStdout: []string{"basic", "basically", "beginner"},
+		},
+		{
+			name:       "appends empty arg when cursor is past the last word",
+			args:       []string{"2", "mw"},
+			want:       true,
+			wantStdout: []string{"100%.txt", "one", "two"},
+		},
+		{
+			name:       "prints suggestions verbatim",
+			args:       []string{"2", "mw", "1"},
+			want:       true,
+			wantStdout: []string{"100%.txt"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			completor := &Completor{
+				SuggestionFetcher: &ListFetcher{Options: []string{"one", "two", "100%.txt"}},
+			}
+			tcos := &TestCommandOS{}
+			err := RunAutocomplete(tcos, branchCommand(NoopExecutor, completor), test.args)
+			if got := err == nil; got != test.want {
+				t.Errorf("RunAutocomplete(%v) returned error %v; want success: %v", test.args, err, test.want)
+			}
+			if diff := cmp.Diff(test.wantStdout, tcos.GetStdout()); diff != "" {
+				t.Errorf("RunAutocomplete(%v) produced stdout diff (-want, +got):\n%s", test.args, diff)
+			}
+			if diff := cmp.Diff(test.wantStderr, tcos.GetStderr()); diff != "" {
+				t.Errorf("RunAutocomplete(%v) produced stderr diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestExecutableScript(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		executables [][]string
+		want        string
+	}{
+		{
+			name: "returns empty script for no executables",
+		},
+		{
+			name:        "skips empty executables",
+			executables: [][]string{nil, {}},
+		},
+		{
+			name:        "quotes words",
+			executables: [][]string{{"cd", "it's here"}},
+			want:        "'cd' 'it'\\''s here'\n",
+		},
+		{
+			name: "writes multiple executables",
+			executables: [][]string{
+				{"export", "A=$B"},
+				{"echo", "one\ntwo", ""},
+			},
+			want: "'export' 'A=$B'\n'echo' 'one\ntwo' ''\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, ExecutableScript(test.executables...)); diff != "" {
+				t.Errorf("ExecutableScript(%v) returned diff (-want, +got):\n%s", test.executables, diff)
+			}
+		})
+	}
+}
+
+func TestExecutableScriptRuns(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	words := []string{"with space", `"double"`, "it's", "$HOME", "`pwd`", "new\nline", `back\slash`, "*", ""}
+	script := ExecutableScript(append([]string{"printf", "%s|"}, words...))
+	out, err := exec.Command("bash", "-c", script).Output()
+	if err != nil {
+		t.Fatalf("failed to run executable script: %v", err)
+	}
+	got := strings.Split(strings.TrimSuffix(string(out), "|"), "|")
+	if diff := cmp.Diff(words, got); diff != "" {
+		t.Errorf("executable script produced diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestBashWrapper(t *testing.T) {
+	want := strings.Join([]string{
+		"# Wrapper for my-cli.",
+		"function _my_cli_execute {",
+		`  local tmpFile="$(mktemp)"`,
+		`  COMMANDS_EXECUTABLE_FILE="$tmpFile" '/usr/bin/it'\''s' execute 'my-cli' "$@"`,
+		"  local errorCode=$?",
+		`  if [ "$errorCode" -eq 0 ] && [ -s "$tmpFile" ]; then`,
+		`    source "$tmpFile"`,
+		"    errorCode=$?",
+		"  fi",
+		`  rm -f "$tmpFile"`,
+		"  return $errorCode",
+		"}",
+		"alias 'my-cli'=_my_cli_execute",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, BashWrapper("/usr/bin/it's", "my-cli")); diff != "" {
+		t.Errorf("BashWrapper() returned diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestBashWrapperScript(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	for _, test := range []struct {
+		name        string
+		executables [][]string
+		exitCode    int
+		want        []string
+	}{
+		{
+			name: "runs binary without executables",
+			want: []string{"args: execute my-cli one two three", "exit: 0", "dir: start", "var: "},
+		},
+		{
+			name: "sources executables",
+			executables: [][]string{
+				{"cd", "sub dir"},
+				{"export", "MY_VAR=it's $HOME"},
+			},
+			want: []string{"args: execute my-cli one two three", "exit: 0", "dir: sub dir", "var: it's $HOME"},
+		},
+		{
+			name:        "doesn't source executables if binary fails",
+			executables: [][]string{{"cd", "sub dir"}},
+			exitCode:    3,
+			want:        []string{"args: execute my-cli one two three", "exit: 3", "dir: start", "var: "},
+		},
+		{
+			name:        "returns error if executable fails",
+			executables: [][]string{{"cd", "missing"}},
+			want:        []string{"args: execute my-cli one two three", "exit: 1", "dir: start", "var: "},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "bash_wrapper_test")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			for _, d := range []string{"start", filepath.Join("start", "sub dir")} {
+				if err := os.Mkdir(filepath.Join(dir, d), 0755); err != nil {
+					t.Fatalf("failed to create dir: %v", err)
+				}
+			}
+
+			binary := filepath.Join(dir, "binary")
+			binaryScript := strings.Join([]string{
+				"#!/bin/bash",
+				`echo "args: $@"`,
+				fmt.Sprintf("cat > \"$%s\" << 'EOF'", ExecutableFileEnv),
+				ExecutableScript(test.executables...) + "EOF",
+				fmt.Sprintf("exit %d", test.exitCode),
+			}, "\n")
+			if err := ioutil.WriteFile(binary, []byte(binaryScript), 0755); err != nil {
+				t.Fatalf("failed to write binary: %v", err)
+			}
+
+			script := strings.Join([]string{
+				"shopt -s expand_aliases",
+				BashWrapper(binary, "my-cli"),
+				fmt.Sprintf("cd %s", quoteBash(filepath.Join(dir, "start"))),
+				"my-cli one two three 2> /dev/null",
+				`echo "exit: $?"`,
+				`echo "dir: $(basename "$PWD")"`,
+				`echo "var: $MY_VAR"`,
+			}, "\n")
+			out, err := exec.Command("bash", "-c", script).Output()
+			if err != nil {
+				t.Fatalf("failed to run wrapper script: %v", err)
+			}
+			got := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrapper script produced diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -88,22 +89,30 @@ func parseArgs(unparsedArgs []string) ([]string, *rune) {
 // Option is a way for CLIs to define additional configuration that isn't easy
 // or feasible exclusively in go.
 type Option struct {
-	// SetupCommand is a bash script that runs prior to the CLI. It's output
-	// is passed as arguments to the command.
+	// SetupCommand is a bash script that runs prior to the CLI (see
+	// ExecuteWithOption). Its output is written to OptionInfo.SetupOutputFile.
 	SetupCommand string
+	// ConfigFile is a JSON file that maps config keys to flag values (see
+	// ConfigKey). Values may be strings, numbers, bools or lists of them.
+	ConfigFile string
 }
 
 // OptionInfo is passed to CLIs and contains info about the command's Option.
 type OptionInfo struct {
+	// Name is the name of the CLI and is shown on its help page.
+	Name string
 	// SetupOutputFile contains the output from Option.SetupCommand
 	SetupOutputFile string
+	// Config contains the values from Option.ConfigFile.
+	Config map[string][]string
 }
 
 // Command is an interface for a CLI that can be written in go.
 type Command interface {
-	Complete([]string) *Completion
-	Execute(CommandOS, []string, *OptionInfo) (*ExecutorResponse, bool)
+	Complete([]string) (*Completion, error)
+	Execute(CommandOS, []string, *OptionInfo) (*ExecutorResponse, error)
 	Usage() []string
+	StructuredUsage() *CommandUsage
 }
 
 // CommandOS provides OS-related objects to executors
@@ -200,125 +209,309 @@ func (tcos *TestCommandOS) GetStderr() []string {
 
 // ExecutorResponse is the response returned by a command.
 type ExecutorResponse struct {
-	// Executable is another command that should be run.
+	// Actions are run, in order, by the shell that invoked the command.
+	Actions []ShellAction
+	// Executable is another command that should be run (after all Actions).
 	Executable []string
 }
 
 // Executor executes a commands with the given positional arguments and flags.
-type Executor func(cos CommandOS, args map[string]*Value, flags map[string]*Value, oi *OptionInfo) (*ExecutorResponse, bool)
+// Returned errors that aren't a UsageError or ValidationError are wrapped in
+// an ExecutorError.
+type Executor func(cos CommandOS, args map[string]*Value, flags map[string]*Value, oi *OptionInfo) (*ExecutorResponse, error)
+
+// BoolExecutor is an executor that writes its own errors to stderr and
+// returns false on failure. Use FromBoolExecutor to convert it to an Executor.
+type BoolExecutor func(cos CommandOS, args map[string]*Value, flags map[string]*Value, oi *OptionInfo) (*ExecutorResponse, bool)
+
+// FromBoolExecutor returns an Executor that runs be. Failures are returned as
+// an ExecutorError that isn't written to stderr again.
+func FromBoolExecutor(be BoolExecutor) Executor {
+	return func(cos CommandOS, args, flags map[string]*Value, oi *OptionInfo) (*ExecutorResponse, error) {
+		resp, ok := be(cos, args, flags, oi)
+		if !ok {
+			return nil, &ExecutorError{Err: fmt.Errorf("executor failed"), reported: true}
+		}
+		return resp, nil
+	}
+}
 
 // NoopExecutor is an Executor that does nothing.
-func NoopExecutor(_ CommandOS, _ map[string]*Value, _ map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
-	return nil, true
+func NoopExecutor(_ CommandOS, _ map[string]*Value, _ map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
+	return nil, nil
 }
 
 // TerminusCommand is a command that processes dynamic arguments and flags.
 type TerminusCommand struct {
-	Args     []Arg
-	Flags    []Flag
-	Executor Executor
+	// Description is displayed in help output, generated docs and completion
+	// suggestions in shells that support descriptions.
+	Description string
+	Args        []Arg
+	Flags       []Flag
+	// FlagConstraints are checked after the flags are parsed.
+	FlagConstraints []FlagConstraint
+	Executor        Executor
 }
 
 // CommandBranch is a command that splits into other commands depending on positional arguments.
 type CommandBranch struct {
+	// Description is displayed in help output, generated docs and completion
+	// suggestions in shells that support descriptions. The TerminusCommand's
+	// description is used if empty.
+	Description                  string
 	Subcommands                  map[string]Command
 	TerminusCommand              *TerminusCommand
 	IgnoreSubcommandAutocomplete bool
+	// PrefixMatching lets a unique prefix of a subcommand's name select
+	// the subcommand (e.g. "de" selects "delete" unless another subcommand
+	// also starts with "de").
+	PrefixMatching bool
+	// SubcommandInfo contains additional metadata for the subcommands, keyed
+	// by the subcommand's name in Subcommands.
+	SubcommandInfo map[string]*SubcommandInfo
 }
 
-// Usage returns the usage info
-func (cb *CommandBranch) Usage() []string {
-	usage := make([]string, 0, len(cb.Subcommands)*5)
+// SubcommandInfo is additional metadata for a subcommand of a CommandBranch.
+type SubcommandInfo struct {
+	// Aliases are alternate names that select the subcommand. Aliases are
+	// only suggested if the subcommand's name isn't.
+	Aliases []string
+	// Hidden subcommands can be executed but aren't included in usage,
+	// completion suggestions or prefix matches.
+	Hidden bool
+	// Deprecated is the warning written to stderr when the subcommand is
+	// executed (e.g. `use "remove" instead`). Deprecated subcommands aren't
+	// suggested in completions.
+	Deprecated string
+}
 
-	keys := make([]string, 0, len(cb.Subcommands))
+// info returns the metadata of the named subcommand.
+func (cb *CommandBranch) info(name string) *SubcommandInfo {
+	if si := cb.SubcommandInfo[name]; si != nil {
+		return si
+	}
+	return &SubcommandInfo{}
+}
+
+// subcommandNames returns the sorted names of the branch's visible
+// subcommands.
+func (cb *CommandBranch) subcommandNames() []string {
+	names := make([]string, 0, len(cb.Subcommands))
 	for k := range cb.Subcommands {
-		keys = append(keys, k)
+		if !cb.info(k).Hidden {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// alias returns the name of the subcommand with the given alias. Aliases
+// that collide with a subcommand name are ignored.
+func (cb *CommandBranch) alias(arg string) (string, bool) {
+	if _, ok := cb.Subcommands[arg]; ok {
+		return "", false
+	}
+	for name, si := range cb.SubcommandInfo {
+		if _, ok := cb.Subcommands[name]; !ok || si == nil {
+			continue
+		}
+		for _, a := range si.Aliases {
+			if a == arg {
+				return name, true
+			}
+		}
 	}
-	sort.Strings(keys)
+	return "", false
+}
 
-	// TODO: add or (|) symbols.
-	for _, k := range keys {
-		v := cb.Subcommands[k]
-		usage = append(usage, k)
-		usage = append(usage, v.Usage()...)
-		usage = append(usage, "\n")
+// subcommand returns the subcommand selected by arg and its name.
+func (cb *CommandBranch) subcommand(arg string) (string, Command, bool) {
+	if sc, ok := cb.Subcommands[arg]; ok {
+		return arg, sc, true
+	}
+	if name, ok := cb.alias(arg); ok {
+		return name, cb.Subcommands[name], true
+	}
+	if !cb.PrefixMatching || arg == "" {
+		return "", nil, false
 	}
 
-	if cb.TerminusCommand == nil {
-		return usage
+	var match string
+	for _, k := range cb.subcommandNames() {
+		for _, n := range append([]string{k}, cb.info(k).Aliases...) {
+			if !strings.HasPrefix(n, arg) || match == k {
+				continue
+			}
+			if match != "" {
+				return "", nil, false
+			}
+			match = k
+		}
+	}
+	if match == "" {
+		return "", nil, false
+	}
+	return match, cb.Subcommands[match], true
+}
+
+// commandDescription returns the description of a command.
+func commandDescription(c Command) string {
+	switch t := c.(type) {
+	case *TerminusCommand:
+		return t.Description
+	case *CommandBranch:
+		if t.Description == "" && t.TerminusCommand != nil {
+			return t.TerminusCommand.Description
+		}
+		return t.Description
 	}
-	return append(usage, cb.TerminusCommand.Usage()...)
+	return c.StructuredUsage().Description
+}
+
+// Usage returns the usage info (see UsageText).
+func (cb *CommandBranch) Usage() []string {
+	return UsageText(cb.StructuredUsage())
+}
+
+// StructuredUsage returns the structured usage info for the branch.
+func (cb *CommandBranch) StructuredUsage() *CommandUsage {
+	cu := &CommandUsage{}
+	if cb.TerminusCommand != nil {
+		cu = cb.TerminusCommand.StructuredUsage()
+	}
+	if cb.Description != "" {
+		cu.Description = cb.Description
+	}
+
+	for _, k := range cb.subcommandNames() {
+		si := cb.info(k)
+		cu.Subcommands = append(cu.Subcommands, &SubcommandUsage{
+			Name:       k,
+			Aliases:    si.Aliases,
+			Deprecated: si.Deprecated,
+			Usage:      cb.Subcommands[k].StructuredUsage(),
+		})
+	}
+	return cu
 }
 
 // Execute executes the corresponding subcommand.
-func (cb *CommandBranch) Execute(cos CommandOS, args []string, oi *OptionInfo) (*ExecutorResponse, bool) {
+func (cb *CommandBranch) Execute(cos CommandOS, args []string, oi *OptionInfo) (*ExecutorResponse, error) {
 	if len(args) == 0 {
 		if cb.TerminusCommand == nil {
-			cos.Stderr("more args required")
-			return nil, false
+			return nil, usageErrorf("more args required")
 		}
 		return cb.TerminusCommand.Execute(cos, args, oi)
 	}
 
-	if sc, ok := cb.Subcommands[args[0]]; ok {
+	if name, sc, ok := cb.subcommand(args[0]); ok {
+		if d := cb.info(name).Deprecated; d != "" {
+			cos.Stderr("subcommand %q is deprecated: %s", name, d)
+		}
 		return sc.Execute(cos, args[1:], oi)
 	}
 
+	hint := didYouMean(args[0], cb.subcommandNames())
 	if cb.TerminusCommand == nil {
-		cos.Stderr("unknown subcommand and no terminus command defined")
-		return nil, false
+		return nil, usageErrorf("unknown subcommand and no terminus command defined%s", hint)
+	}
+	resp, err := cb.TerminusCommand.Execute(cos, args, oi)
+	if err != nil && hint != "" {
+		// The first arg may have been a misspelled subcommand.
+		return nil, withHint(err, hint)
 	}
-	return cb.TerminusCommand.Execute(cos, args, oi)
+	return resp, err
 }
 
 // Complete returns autocomplete suggestions.
-func (cb *CommandBranch) Complete(args []string) *Completion {
+func (cb *CommandBranch) Complete(args []string) (*Completion, error) {
 	// Return subcommands and terminus command suggestions if only one argument.
 	if len(args) <= 1 {
+		tracef("completing subcommands for %q", args)
 		suggestions := make([]string, 0, len(cb.Subcommands))
+		descriptions := map[string]string{}
 
 		if !cb.IgnoreSubcommandAutocomplete {
-			for k := range cb.Subcommands {
-				suggestions = append(suggestions, k)
+			for _, k := range cb.subcommandNames() {
+				si := cb.info(k)
+				if si.Deprecated != "" {
+					continue
+				}
+				// Only suggest an alias if the name itself doesn't match.
+				if names := filter(args, append([]string{k}, si.Aliases...)); len(names) > 0 {
+					suggestions = append(suggestions, names[0])
+					if d := commandDescription(cb.Subcommands[k]); d != "" {
+						descriptions[names[0]] = d
+					}
+				}
 			}
-			suggestions = filter(args, suggestions)
+		}
+		if len(descriptions) == 0 {
+			descriptions = nil
 		}
 
 		if cb.TerminusCommand != nil {
 			// the autocomplete command will filter if needed
-			c := cb.TerminusCommand.Complete(args)
+			tracef("completing terminus command")
+			c, err := cb.TerminusCommand.Complete(args)
+			if err != nil {
+				return nil, err
+			}
 			if c == nil {
 				c = &Completion{}
 			}
 			c.Suggestions = append(c.Suggestions, suggestions...)
-			return c
+			for s, d := range descriptions {
+				if c.Descriptions == nil {
+					c.Descriptions = map[string]string{}
+				}
+				c.Descriptions[s] = d
+			}
+			return c, nil
 		}
 
 		return &Completion{
-			Suggestions: suggestions,
-		}
+			Suggestions:  suggestions,
+			Descriptions: descriptions,
+		}, nil
 	}
 
 	// If first argument is a subcommand, then return it's suggestions
-	if sc, ok := cb.Subcommands[args[0]]; ok {
+	if name, sc, ok := cb.subcommand(args[0]); ok {
+		tracef("matched subcommand %q", name)
 		return sc.Complete(args[1:])
 	}
 
 	// Otherwise, we only have the terminus command left.
 	if cb.TerminusCommand != nil {
+		tracef("no subcommand matched %q; completing terminus command", args[0])
 		return cb.TerminusCommand.Complete(args)
 	}
 
-	return nil
+	tracef("no subcommand matched %q and no terminus command defined", args[0])
+	return nil, nil
 }
 
-// Execute executes the given unparsed command.
-func Execute(cos CommandOS, c Command, args []string, oi *OptionInfo) (*ExecutorResponse, bool) {
+// Execute executes the given unparsed command. Any returned error is also
+// written to stderr (see ExitCode for converting it to an exit code).
+func Execute(cos CommandOS, c Command, args []string, oi *OptionInfo) (*ExecutorResponse, error) {
 	// We don't need to parse args here because we're not doing
 	// our own modification and interpretation of args like we do
 	// with autocomplete.
-	// TODO: check for help flag and print usage.
-	return c.Execute(cos, args, oi)
+	if helpRequested(c, args) {
+		var name string
+		if oi != nil {
+			name = oi.Name
+		}
+		PrintHelp(cos, name, c, args)
+		return nil, nil
+	}
+	resp, err := c.Execute(cos, args, oi)
+	if err != nil && !reported(err) {
+		cos.Stderr("%s", err)
+	}
+	return resp, err
 }
 
 func filter(args, suggestions []string) []string {
@@ -336,15 +529,31 @@ func filter(args, suggestions []string) []string {
 	return filtered
 }
 
-// Autocomplete completes the given unparsed command.
-func Autocomplete(c Command, unparsedArgs []string, cursorIdx int) []string {
+// complete returns the sorted completion for the given unparsed command and
+// the quotation character that the last argument started with (if any).
+func complete(c Command, unparsedArgs []string, cursorIdx int) (*Completion, *rune, error) {
+	args, delimiter := completionArgs(unparsedArgs, cursorIdx)
+	completion, err := completeArgs(c, args)
+	return completion, delimiter, err
+}
+
+// completionArgs parses the given unparsed command and adds an empty argument
+// if the cursor is past the last argument.
+func completionArgs(unparsedArgs []string, cursorIdx int) ([]string, *rune) {
 	args, delimiter := parseArgs(unparsedArgs)
 
 	if cursorIdx > len(args) || len(args) == 0 {
 		args = append(args, "")
 	}
+	return args, delimiter
+}
 
-	completion := c.Complete(args)
+// completeArgs returns the sorted completion for the given parsed arguments.
+func completeArgs(c Command, args []string) (*Completion, error) {
+	completion, err := c.Complete(cp(args))
+	if err != nil {
+		return nil, err
+	}
 	if completion == nil {
 		completion = &Completion{}
 	}
@@ -355,7 +564,53 @@ func Autocomplete(c Command, unparsedArgs []string, cursorIdx int) []string {
 	} else {
 		sort.Strings(predictions)
 	}
+	return completion, nil
+}
+
+// joinFlagValues rejoins the "--name=value" arguments that bash splits into
+// separate words (COMP_WORDBREAKS includes "=" by default). It also returns the
+// adjusted cursor index and the part of the last argument that precedes the
+// word that bash is completing.
+func joinFlagValues(unparsedArgs []string, cursorIdx int) ([]string, int, string) {
+	args := make([]string, 0, len(unparsedArgs))
+	var split string
+	for i := 0; i < len(unparsedArgs); i++ {
+		split = ""
+		if unparsedArgs[i] != "=" || len(args) == 0 {
+			args = append(args, unparsedArgs[i])
+			continue
+		}
+
+		last := args[len(args)-1]
+		if !strings.HasPrefix(last, "--") || last == flagTerminator || strings.Contains(last, "=") {
+			args = append(args, unparsedArgs[i])
+			continue
+		}
+
+		split = last
+		args[len(args)-1] += "="
+		cursorIdx--
+		if i+1 < len(unparsedArgs) {
+			i++
+			split += "="
+			args[len(args)-1] += unparsedArgs[i]
+			cursorIdx--
+		}
+	}
+	return args, cursorIdx, split
+}
+
+// Autocomplete completes the given unparsed command.
+func Autocomplete(c Command, unparsedArgs []string, cursorIdx int) ([]string, error) {
+	unparsedArgs, cursorIdx, split := joinFlagValues(unparsedArgs, cursorIdx)
+	completion, delimiter, err := complete(c, unparsedArgs, cursorIdx)
+	if err != nil {
+		return nil, err
+	}
+	predictions := completion.Suggestions
 	for i, prediction := range predictions {
+		prediction = strings.TrimPrefix(prediction, split)
+		predictions[i] = prediction
 		if strings.Contains(prediction, " ") {
 			if delimiter == nil {
 				// TODO: default delimiter behavior should be defined by command?
@@ -370,19 +625,36 @@ func Autocomplete(c Command, unparsedArgs []string, cursorIdx int) []string {
 		predictions = append(predictions, " ")
 	}
 
-	return predictions
+	return predictions, nil
 }
 
-// Usage returns usage info about the command.
+// Usage returns usage info about the command (see UsageText).
 func (tc *TerminusCommand) Usage() []string {
-	usage := make([]string, 0, 3*(len(tc.Args)+len(tc.Flags)))
+	return UsageText(tc.StructuredUsage())
+}
+
+// StructuredUsage returns the structured usage info for the command.
+func (tc *TerminusCommand) StructuredUsage() *CommandUsage {
+	cu := &CommandUsage{Description: tc.Description}
 	for _, a := range tc.Args {
-		usage = append(usage, a.Usage()...)
+		cu.Args = append(cu.Args, a.StructuredUsage())
+	}
+
+	required := map[string]bool{}
+	for _, c := range tc.FlagConstraints {
+		if rf, ok := c.(*requiredFlags); ok {
+			for _, n := range rf.names {
+				required[n] = true
+			}
+		}
+		cu.Constraints = append(cu.Constraints, c.Usage())
 	}
 	for _, f := range tc.Flags {
-		usage = append(usage, f.Usage()...)
+		au := f.StructuredUsage()
+		au.Required = required[au.Name]
+		cu.Flags = append(cu.Flags, au)
 	}
-	return usage
+	return cu
 }
 
 func (tc *TerminusCommand) flagMap() map[string]Flag {
@@ -393,12 +665,151 @@ func (tc *TerminusCommand) flagMap() map[string]Flag {
 			flagMap[fmt.Sprintf("-%c", flag.ShortName())] = flag
 		}
 	}
+
+	// Bool flags can be negated with "--no-<name>" unless another flag has
+	// that name.
+	for _, flag := range tc.Flags {
+		bf, ok := flag.(*boolFlagProcessor)
+		name := fmt.Sprintf("--no-%s", flag.Name())
+		if _, exists := flagMap[name]; ok && !exists {
+			flagMap[name] = bf.negation()
+		}
+	}
 	return flagMap
 }
 
-// Execute loads flags and args and then runs it's executor.
-func (tc *TerminusCommand) Execute(cos CommandOS, args []string, oi *OptionInfo) (*ExecutorResponse, bool) {
+// isBoolFlag returns whether the flag doesn't take any values.
+func isBoolFlag(f Flag) bool {
+	switch f.(type) {
+	case *boolFlagProcessor, *countFlagProcessor:
+		return true
+	}
+	return false
+}
+
+// shortFlagCluster returns the flags in a cluster of short flags (e.g. "-rv"
+// returns "-r" and "-v"). Every flag in a cluster except the last must be a
+// bool flag. Returns false if arg isn't a cluster of at least two flags.
+func shortFlagCluster(arg string, flagMap map[string]Flag) ([]string, bool) {
+	if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+		return nil, false
+	}
+	shortNames := []rune(arg[1:])
+	if len(shortNames) < 2 {
+		return nil, false
+	}
+
+	var flags []string
+	for i, r := range shortNames {
+		name := fmt.Sprintf("-%c", r)
+		f, ok := flagMap[name]
+		if !ok || (i < len(shortNames)-1 && !isBoolFlag(f)) {
+			return nil, false
+		}
+		flags = append(flags, name)
+	}
+	return flags, true
+}
+
+// expandShortFlagCluster replaces the cluster of short flags at args[idx]
+// with the individual flags. Returns false if args[idx] isn't a cluster.
+func expandShortFlagCluster(args []string, idx int, flagMap map[string]Flag) ([]string, bool) {
+	flags, ok := shortFlagCluster(args[idx], flagMap)
+	if !ok {
+		return args, false
+	}
+	expanded := make([]string, 0, len(args)+len(flags)-1)
+	expanded = append(expanded, args[:idx]...)
+	expanded = append(expanded, flags...)
+	return append(expanded, args[idx+1:]...), true
+}
+
+// inlineFlag is an argument that includes the value of a flag (e.g.
+// "--number=5" or "-n5").
+type inlineFlag struct {
+	// bools are the bool flags that precede the flag in a short flag cluster
+	// (e.g. "-r" in "-rn5").
+	bools []string
+	flag  string
+	// prefix is the part of the argument before the value.
+	prefix string
+	value  string
+}
+
+// values returns the flag's values. List flag values are separated by commas.
+func (inf *inlineFlag) values(flag Flag) []string {
+	if _, ok := flag.(*listArgProcessor); ok {
+		return strings.Split(inf.value, ",")
+	}
+	return []string{inf.value}
+}
+
+// inlineFlagValue parses an argument of the form "--name=value" or
+// "-nvalue". Short flags may be preceded by a cluster of bool flags (e.g.
+// "-rn5"). Returns false if arg doesn't include the value of a known flag.
+func inlineFlagValue(arg string, flagMap map[string]Flag) (*inlineFlag, bool) {
+	if strings.HasPrefix(arg, "--") {
+		idx := strings.Index(arg, "=")
+		if idx < 0 {
+			return nil, false
+		}
+		if _, ok := flagMap[arg[:idx]]; !ok {
+			return nil, false
+		}
+		return &inlineFlag{
+			flag:   arg[:idx],
+			prefix: arg[:idx+1],
+			value:  arg[idx+1:],
+		}, true
+	}
+
+	if !strings.HasPrefix(arg, "-") {
+		return nil, false
+	}
+	shortNames := []rune(arg[1:])
+	var bools []string
+	for i, r := range shortNames {
+		name := fmt.Sprintf("-%c", r)
+		f, ok := flagMap[name]
+		if !ok {
+			return nil, false
+		}
+		if isBoolFlag(f) {
+			bools = append(bools, name)
+			continue
+		}
+		if i == len(shortNames)-1 {
+			return nil, false
+		}
+		return &inlineFlag{
+			bools:  bools,
+			flag:   name,
+			prefix: fmt.Sprintf("-%s", string(shortNames[:i+1])),
+			value:  string(shortNames[i+1:]),
+		}, true
+	}
+	return nil, false
+}
+
+// flagTerminator stops flag parsing. All arguments after it are positional.
+const flagTerminator = "--"
+
+// splitAtTerminator splits args into the arguments before and after the first
+// flagTerminator and returns whether the terminator was present.
+func splitAtTerminator(args []string) ([]string, []string, bool) {
+	for i, arg := range args {
+		if arg == flagTerminator {
+			return args[:i], args[i+1:], true
+		}
+	}
+	return args, nil, false
+}
+
+// Execute loads flags and args and then runs it's executor. Arguments after
+// "--" are only used for args.
+func (tc *TerminusCommand) Execute(cos CommandOS, args []string, oi *OptionInfo) (*ExecutorResponse, error) {
 	flagMap := tc.flagMap()
+	args, positional, _ := splitAtTerminator(args)
 
 	flagValues := map[string]*Value{}
 	argValues := map[string]*Value{}
@@ -406,6 +817,40 @@ func (tc *TerminusCommand) Execute(cos CommandOS, args []string, oi *OptionInfo)
 	// Populate flags.
 	for idx := 0; idx < len(args); {
 		arg := args[idx]
+		if inline, ok := inlineFlagValue(arg, flagMap); ok {
+			for _, b := range inline.bools {
+				if _, err := flagMap[b].ProcessExecuteArgs(nil, argValues, flagValues); err != nil {
+					return nil, err
+				}
+			}
+			flag := flagMap[inline.flag]
+			if bf, ok := flag.(*boolFlagProcessor); ok {
+				if err := bf.processValue(inline.value, flagValues); err != nil {
+					return nil, err
+				}
+				args = append(args[:idx], args[idx+1:]...)
+				continue
+			}
+
+			values := inline.values(flag)
+			n, err := flag.ProcessExecuteArgs(values, argValues, flagValues)
+			if err != nil {
+				return nil, err
+			}
+			if n < len(values) {
+				return nil, usageErrorf("too many values provided for flag %q: %v", flag.Name(), values[n:])
+			}
+			args = append(args[:idx], args[idx+1:]...)
+			continue
+		}
+
+		// Clusters are only expanded here so values of the preceding flags
+		// (e.g. "--message -vj") are kept as is.
+		if expanded, ok := expandShortFlagCluster(args, idx, flagMap); ok {
+			args = expanded
+			continue
+		}
+
 		flag, ok := flagMap[arg]
 		if !ok {
 			idx++
@@ -414,91 +859,350 @@ func (tc *TerminusCommand) Execute(cos CommandOS, args []string, oi *OptionInfo)
 
 		n, err := flag.ProcessExecuteArgs(args[(idx+1):], argValues, flagValues)
 		if err != nil {
-			cos.Stderr(err.Error())
-			return nil, false
+			return nil, err
 		}
 		args = append(args[:idx], args[idx+n+1:]...)
 	}
 
 	// Populate args
+	args = append(args, positional...)
 	for _, arg := range tc.Args {
 		n, err := arg.ProcessExecuteArgs(args, argValues, flagValues)
 		if err != nil {
-			cos.Stderr(err.Error())
-			return nil, false
+			return nil, err
 		}
 		args = args[n:]
 	}
 
 	if len(args) != 0 {
-		cos.Stderr("extra unknown args (%v)", args)
-		return nil, false
+		return nil, usageErrorf("extra unknown args (%v)%s", args, tc.flagHint(args, flagMap))
+	}
+
+	// Fallback values of flags that conflict with flags provided on the
+	// command line are ignored.
+	conflicts := conflictingFlags(tc.FlagConstraints, flagValues)
+	for _, flag := range tc.Flags {
+		if !conflicts[flag.Name()] {
+			if err := setFallback(flag, argValues, flagValues, oi); err != nil {
+				return nil, err
+			}
+		}
+		setDefault(flag, flagValues)
+	}
+	for _, arg := range tc.Args {
+		setDefault(arg, argValues)
+	}
+
+	if err := checkFlagConstraints(tc.FlagConstraints, flagValues); err != nil {
+		return nil, err
 	}
 
 	if tc.Executor == nil {
-		cos.Stderr("no executor defined for command")
-		return nil, false
+		return nil, &ExecutorError{Err: fmt.Errorf("no executor defined for command")}
+	}
+
+	resp, err := tc.Executor(cos, argValues, flagValues, oi)
+	return resp, executorError(err)
+}
+
+// flagHint returns a hint with the flags that are similar to the first
+// unknown arg that looks like a flag.
+func (tc *TerminusCommand) flagHint(unknown []string, flagMap map[string]Flag) string {
+	names := make([]string, 0, len(flagMap))
+	for k := range flagMap {
+		names = append(names, k)
+	}
+	for _, arg := range unknown {
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			return didYouMean(strings.SplitN(arg, "=", 2)[0], names)
+		}
+	}
+	return ""
+}
+
+// configurable is an Arg or Flag that can be configured with setting options
+// (e.g. Default).
+type configurable interface {
+	Name() string
+	settings() *argSettings
+}
+
+// setDefault sets the default value of a if it wasn't provided.
+func setDefault(a interface{}, values map[string]*Value) {
+	c, ok := a.(configurable)
+	if !ok {
+		return
+	}
+	if _, provided := values[c.Name()]; provided {
+		return
+	}
+	if dv := c.settings().defaultValue; dv != nil {
+		values[c.Name()] = dv.unprovided()
+	}
+}
+
+// setFallback sets the value of a flag that wasn't provided on the command
+// line from its environment variable or, if that isn't set, from its key in
+// the config file.
+func setFallback(flag Flag, args, flags map[string]*Value, oi *OptionInfo) error {
+	c, ok := flag.(configurable)
+	if !ok {
+		return nil
+	}
+	if _, provided := flags[flag.Name()]; provided {
+		return nil
+	}
+
+	as := c.settings()
+	if as.envVar != "" {
+		if v := getenv(as.envVar); v != "" {
+			values := []string{v}
+			if _, ok := flag.(*listArgProcessor); ok {
+				values = strings.Split(v, ",")
+			}
+			if err := processFallback(flag, values, args, flags); err != nil {
+				return validationError(flag.Name(), fmt.Errorf("invalid value for environment variable %s: %v", as.envVar, err))
+			}
+			flags[flag.Name()].source = EnvSource
+			return nil
+		}
+	}
+
+	if as.configKey != "" && oi != nil {
+		if values, ok := oi.Config[as.configKey]; ok {
+			if err := processFallback(flag, values, args, flags); err != nil {
+				return validationError(flag.Name(), fmt.Errorf("invalid value for config key %q: %v", as.configKey, err))
+			}
+			flags[flag.Name()].source = ConfigSource
+		}
 	}
+	return nil
+}
 
-	return tc.Executor(cos, argValues, flagValues, oi)
+// processFallback sets a flag from values that weren't provided on the command
+// line.
+func processFallback(flag Flag, values []string, args, flags map[string]*Value) error {
+	if bf, ok := flag.(*boolFlagProcessor); ok {
+		if len(values) != 1 {
+			return fmt.Errorf("expected a single value: %v", values)
+		}
+		return bf.processValue(values[0], flags)
+	}
+	if _, ok := flag.(*countFlagProcessor); ok {
+		if len(values) != 1 {
+			return fmt.Errorf("expected a single value: %v", values)
+		}
+		i, err := strconv.Atoi(values[0])
+		if err != nil {
+			return fmt.Errorf("argument should be an integer: %v", err)
+		}
+		flags[flag.Name()] = IntValue(i)
+		return nil
+	}
+
+	n, err := flag.ProcessExecuteArgs(values, args, flags)
+	if err != nil {
+		return err
+	}
+	if n < len(values) {
+		return fmt.Errorf("too many values: %v", values[n:])
+	}
+	return nil
 }
 
 // Complete returns all possible autocomplete suggestions for the given list of arguments.
-// TODO: this should return an error so it's easier to debug and test
-func (tc *TerminusCommand) Complete(rawArgs []string) *Completion {
+func (tc *TerminusCommand) Complete(rawArgs []string) (*Completion, error) {
 	flagMap := tc.flagMap()
 
 	flagValues := map[string]*Value{}
 	argValues := map[string]*Value{}
-	args := rawArgs
+	var args, positional []string
+	var terminated bool
+	if len(rawArgs) > 0 {
+		args, positional, terminated = splitAtTerminator(rawArgs[:len(rawArgs)-1])
+		if terminated {
+			positional = append(positional, rawArgs[len(rawArgs)-1])
+		} else {
+			args = append(args, rawArgs[len(rawArgs)-1])
+		}
+	}
+	usedFlags := map[string]bool{}
 	// Don't care if the last argument is a flag because
-	// that is taken care of in the next step.
-	for idx := 0; idx < len(args)-1; {
+	// that is taken care of in the next step (unless it's
+	// after the terminator).
+	pending := 1
+	if terminated {
+		pending = 0
+	}
+	for idx := 0; idx < len(args)-pending; {
 		arg := args[idx]
+		if inline, ok := inlineFlagValue(arg, flagMap); ok {
+			for _, b := range inline.bools {
+				usedFlags[flagMap[b].Name()] = true
+				flagMap[b].ProcessCompleteArgs(nil, argValues, flagValues)
+			}
+			flag := flagMap[inline.flag]
+			usedFlags[flag.Name()] = true
+			flag.ProcessCompleteArgs(inline.values(flag), argValues, flagValues)
+			tracef("flag %q consumed %q", flag.Name(), arg)
+			args = append(args[:idx], args[idx+1:]...)
+			continue
+		}
+
+		if expanded, ok := expandShortFlagCluster(args, idx, flagMap); ok {
+			args = expanded
+			continue
+		}
+
 		flag, ok := flagMap[arg]
 		if !ok {
 			idx++
 			continue
 		}
+		usedFlags[flag.Name()] = true
 
 		n := flag.ProcessCompleteArgs(args[(idx+1):], argValues, flagValues)
-		if n+idx+1 >= len(args) {
+		if !terminated && n+idx+1 >= len(args) {
+			tracef("completing flag %q with %q", flag.Name(), args[len(args)-1])
 			return flag.Complete(args[len(args)-1], argValues, flagValues)
 		}
+		tracef("flag %q consumed %q", flag.Name(), args[idx:idx+n+1])
 		args = append(args[:idx], args[(idx+n+1):]...)
 	}
 
+	if terminated {
+		tracef("completing args after %q", flagTerminator)
+		args = append(args, positional...)
+	}
+
+	// Check if last arg includes a flag value
+	if !terminated && len(args) > 0 {
+		if inline, ok := inlineFlagValue(args[len(args)-1], flagMap); ok {
+			return completeInlineFlag(inline, flagMap, argValues, flagValues)
+		}
+	}
+
 	// Check if last arg is incomplete flag
-	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], "-") {
+	if !terminated && len(args) > 0 && strings.HasPrefix(args[len(args)-1], "-") {
+		conflicts := conflictingFlags(tc.FlagConstraints, flagValues)
 		shortNames := make([]string, 0, len(tc.Flags))
 		names := make([]string, 0, len(tc.Flags))
+		var descriptions map[string]string
 		for _, flag := range tc.Flags {
-			names = append(names, fmt.Sprintf("--%s", flag.Name()))
-			shortNames = append(shortNames, fmt.Sprintf("-%s", string(flag.ShortName())))
+			if conflicts[flag.Name()] {
+				continue
+			}
+			name, shortName := fmt.Sprintf("--%s", flag.Name()), fmt.Sprintf("-%s", string(flag.ShortName()))
+			names = append(names, name)
+			shortNames = append(shortNames, shortName)
+			if d := flag.StructuredUsage().Description; d != "" {
+				if descriptions == nil {
+					descriptions = map[string]string{}
+				}
+				descriptions[name] = d
+				descriptions[shortName] = d
+			}
+		}
+
+		tracef("completing flag names for %q", args[len(args)-1])
+
+		// Suggest the remaining short flags that can be added to a cluster.
+		if cluster, ok := shortFlagCluster(args[len(args)-1], flagMap); ok {
+			return tc.completeShortFlagCluster(args[len(args)-1], cluster, flagMap, usedFlags, argValues, flagValues), nil
 		}
 
 		// Only show full names in this case.
 		if args[len(args)-1] == "-" {
 			return &Completion{
-				Suggestions: filter(args, names),
-			}
+				Suggestions:  filter(args, names),
+				Descriptions: descriptions,
+			}, nil
 		}
 
 		// Otherwise, just return all flags if the last arg is a prefix of any of them.
 		return &Completion{
-			Suggestions: filter(args, append(names, shortNames...)),
-		}
+			Suggestions:  filter(args, append(names, shortNames...)),
+			Descriptions: descriptions,
+		}, nil
 	}
 
 	for _, arg := range tc.Args {
 		n := arg.ProcessCompleteArgs(args, argValues, flagValues)
 		if n >= len(args) {
+			tracef("completing arg %q with %q", arg.Name(), args[len(args)-1])
 			return arg.Complete(args[len(args)-1], argValues, flagValues)
 		}
+		tracef("arg %q consumed %q", arg.Name(), args[:n])
 		args = args[n:]
 	}
 
-	return nil
+	tracef("no arg left to complete %q", args)
+	return nil, nil
+}
+
+// completeInlineFlag completes the value of an inline flag. Only the value
+// after the last comma is completed for list flags. The part of the argument
+// before the completed value is added to each suggestion (or to the Prefix if
+// the suggestions are only for display).
+func completeInlineFlag(inline *inlineFlag, flagMap map[string]Flag, args, flags map[string]*Value) (*Completion, error) {
+	for _, b := range inline.bools {
+		flagMap[b].ProcessCompleteArgs(nil, args, flags)
+	}
+	flag := flagMap[inline.flag]
+	flag.ProcessCompleteArgs(inline.values(flag), args, flags)
+
+	prefix, rawValue := inline.prefix, inline.value
+	if _, ok := flag.(*listArgProcessor); ok {
+		idx := strings.LastIndex(rawValue, ",")
+		prefix, rawValue = prefix+rawValue[:idx+1], rawValue[idx+1:]
+	}
+
+	tracef("completing flag %q with %q", flag.Name(), rawValue)
+	c, err := flag.Complete(rawValue, args, flags)
+	if err != nil || c == nil {
+		return c, err
+	}
+	if c.DontComplete {
+		c.Prefix = prefix + c.Prefix
+		return c, nil
+	}
+
+	var descriptions map[string]string
+	if c.Descriptions != nil {
+		descriptions = map[string]string{}
+	}
+	for i, s := range c.Suggestions {
+		c.Suggestions[i] = prefix + s
+		if d, ok := c.Descriptions[s]; ok {
+			descriptions[prefix+s] = d
+		}
+	}
+	c.Descriptions = descriptions
+	return c, nil
+}
+
+// completeShortFlagCluster returns the cluster followed by the cluster with
+// each unused (and non-conflicting) short flag appended. Count flags can
+// always be appended. Nothing is appended if the last flag in
+// the cluster takes a value.
+func (tc *TerminusCommand) completeShortFlagCluster(arg string, cluster []string, flagMap map[string]Flag, usedFlags map[string]bool, args, flags map[string]*Value) *Completion {
+	suggestions := []string{arg}
+	if !isBoolFlag(flagMap[cluster[len(cluster)-1]]) {
+		return &Completion{Suggestions: suggestions}
+	}
+
+	for _, f := range cluster {
+		usedFlags[flagMap[f].Name()] = true
+		flagMap[f].ProcessCompleteArgs(nil, args, flags)
+	}
+	conflicts := conflictingFlags(tc.FlagConstraints, flags)
+	for _, f := range tc.Flags {
+		_, counter := f.(*countFlagProcessor)
+		if f.ShortName() != 0 && (counter || !usedFlags[f.Name()]) && !conflicts[f.Name()] {
+			suggestions = append(suggestions, fmt.Sprintf("%s%c", arg, f.ShortName()))
+		}
+	}
+	return &Completion{Suggestions: suggestions}
 }
 
 // Arg is a positional argument used by a TerminusCommand.
@@ -506,8 +1210,9 @@ type Arg interface {
 	Name() string
 	ProcessCompleteArgs(rawArgs []string, args, flags map[string]*Value) int
 	ProcessExecuteArgs(rawArgs []string, args, flags map[string]*Value) (int, error)
-	Complete(rawValue string, args, flags map[string]*Value) *Completion
+	Complete(rawValue string, args, flags map[string]*Value) (*Completion, error)
 	Usage() []string
+	StructuredUsage() *ArgUsage
 	// TODO: I believe this can be removed.
 	Optional() bool
 }
@@ -518,6 +1223,7 @@ type Flag interface {
 	ShortName() rune
 	ProcessCompleteArgs(rawArgs []string, args, flags map[string]*Value) int
 	ProcessExecuteArgs(rawArgs []string, args, flags map[string]*Value) (int, error)
-	Complete(rawValue string, args, flags map[string]*Value) *Completion
+	Complete(rawValue string, args, flags map[string]*Value) (*Completion, error)
 	Usage() []string
+	StructuredUsage() *ArgUsage
 }
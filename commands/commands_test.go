@@ -3,6 +3,7 @@ package commands
 // TODO: split this up into separate files (not separate packages).
 
 import (
+	"fmt"
 	"sort"
 	"testing"
 
@@ -11,44 +12,162 @@ import (
 
 func TestUsage(t *testing.T) {
 	for _, test := range []struct {
-		name string
-		cmd  Command
-		want []string
+		name    string
+		cmd     Command
+		columns string
+		want    []string
 	}{
 		{
 			name: "returns proper usage",
 			cmd:  branchCommand(NoopExecutor, &Completor{}),
 			want: []string{
-				// TODO: improve this
-				"advanced", "first", "\n",
-				"foremost", "\n",
-				"liszt", "LIST-ARG", "[LIST-ARG ...]", "--inside|-i", "FLAG_VALUE", "FLAG_VALUE", "\n",
-				"other", "\n",
-				"[", "CB-COMMAND", "CB-COMMAND", "]",
-				"\n",
-				"basic", "VAL_1", "VARIABLE_2", "--american|-a", "--another", "FLAG_VALUE", "--state|-s", "FLAG_VALUE", "\n",
-				"basically", "ANYTHING", "ANYTHING", "ANYTHING", "\n",
-				"beginner", "\n", "dquo", "WHOSE", "WHOSE", "\n",
-				"ignore", "alpha", "\n", "ayo", "\n", "AIGHT", "\n",
-				"intermediate", "SYLLABLE", "SYLLABLE", "SYLLABLE", "--american|-a", "--another", "FLAG_VALUE", "--state|-s", "FLAG_VALUE", "\n",
-				"mw", "ALPHA", "ALPHA", "\n",
-				"prefixes", "ALPHAS", "\n",
-				"sometimes", "OPT_GROUP", "[", "OPT_GROUP", "OPT_GROUP", "OPT_GROUP", "]", "\n",
-				"squo", "WHOSE", "WHOSE", "\n",
-				"valueTypes",
-				"bool", "REQ", "[", "OPT", "]", "--vFlag|-v", "\n",
-				"float", "REQ", "[", "OPT", "]", "--vFlag|-v", "FLAG_VALUE", "\n",
-				"floatList", "REQ", "REQ", "[", "REQ", "]", "--vFlag|-v", "FLAG_VALUE", "FLAG_VALUE", "[", "FLAG_VALUE", "]", "\n",
-				"int", "REQ", "[", "OPT", "]", "--vFlag|-v", "FLAG_VALUE", "\n",
-				"intList", "REQ", "REQ", "[", "REQ", "]", "--vFlag|-v", "FLAG_VALUE", "FLAG_VALUE", "[", "FLAG_VALUE", "]", "\n",
-				"string", "REQ", "[", "OPT", "]", "--vFlag|-v", "FLAG_VALUE", "\n",
-				"stringList", "REQ", "REQ", "[", "REQ", "]", "--vFlag|-v", "FLAG_VALUE", "FLAG_VALUE", "[", "FLAG_VALUE", "]", "\n",
-				"\n",
-				"wave", "ANY", "ANY", "--yourFlag|-y", "FLAG_VALUE", "FLAG_VALUE", "FLAG_VALUE", "\n",
+				"(advanced|basic|basically|beginner|cluster|defaults|dquo|fallbacks|ignore|info|",
+				" inline|intermediate|matching|mw|prefixes|repeatable|sometimes|squo|switches|",
+				" terminator|valueTypes|wave) ...",
+				"  advanced (first|foremost|liszt|other) ...",
+				"  advanced [CB-COMMAND CB-COMMAND]",
+				"    first",
+				"    foremost",
+				"    liszt LIST-ARG [LIST-ARG ...] [OPTIONS]",
+				"      Options: [--inside|-i INSIDE INSIDE]",
+				"    other",
+				"  basic VAL_1 VARIABLE_2 [OPTIONS]",
+				"    Options: [--american|-a] [--another ANOTHER] [--state|-s STATE]",
+				"  basically ANYTHING ANYTHING ANYTHING",
+				"  beginner",
+				"  cluster [FILES ...] [OPTIONS]",
+				"    Options: [--recursive|-r] [--verbose|-v] [--all] [--number|-n NUMBER]",
+				"             [--message|-m MESSAGE]",
+				"  defaults GREETING [NAME] [OPTIONS]",
+				"    Options: [--times|-t TIMES] [--loud|-l] [--tags [TAGS ...]]",
+				"             [--ratio|-r RATIO]",
+				"  dquo WHOSE WHOSE",
+				"  fallbacks [OPTIONS]",
+				"    Options: [--times|-t TIMES] [--tags TAGS [TAGS ...]] [--loud|-l]",
+				"  ignore (alpha|ayo) ...",
+				"  ignore AIGHT",
+				"    alpha",
+				"    ayo",
+				"  info (add|delete|remove) ...",
+				"    add [ITEM]",
+				"    delete [ITEM]",
+				"    remove [ITEM]",
+				"  inline [FILES ...] [OPTIONS]",
+				"    Options: [--recursive|-r] [--number|-n NUMBER] [--name NAME]",
+				"             [--colors|-c COLORS [COLORS COLORS]]",
+				"  intermediate SYLLABLE SYLLABLE SYLLABLE [OPTIONS]",
+				"    Options: [--american|-a] [--another ANOTHER] [--state|-s STATE]",
+				"  matching (add|delete|describe) ...",
+				"    add [ITEM]",
+				"    delete [ITEM]",
+				"    describe [ITEM]",
+				"  mw ALPHA ALPHA",
+				"  prefixes ALPHAS",
+				"  repeatable [FILES ...] [OPTIONS]",
+				"    Options: [--tag|-t TAG] [--size|-s SIZE [SIZE]] [--verbose|-v] [--quiet|-q]",
+				"  sometimes OPT_GROUP [OPT_GROUP OPT_GROUP OPT_GROUP]",
+				"  squo WHOSE WHOSE",
+				"  switches [FILES ...] [OPTIONS]",
+				"    Options: [--verbose|-v] [--force] [--no-force NO-FORCE]",
+				"  terminator [FILES ...] [OPTIONS]",
+				"    Options: [--force|-f] [--colors|-c [COLORS ...]]",
+				"  valueTypes (bool|float|floatList|int|intList|string|stringList) ...",
+				"    bool REQ [OPT] [OPTIONS]",
+				"      Options: [--vFlag|-v]",
+				"    float REQ [OPT] [OPTIONS]",
+				"      Options: [--vFlag|-v VFLAG]",
+				"    floatList REQ REQ [REQ] [OPTIONS]",
+				"      Options: [--vFlag|-v VFLAG VFLAG [VFLAG]]",
+				"    int REQ [OPT] [OPTIONS]",
+				"      Options: [--vFlag|-v VFLAG]",
+				"    intList REQ REQ [REQ] [OPTIONS]",
+				"      Options: [--vFlag|-v VFLAG VFLAG [VFLAG]]",
+				"    string REQ [OPT] [OPTIONS]",
+				"      Options: [--vFlag|-v VFLAG]",
+				"    stringList REQ REQ [REQ] [OPTIONS]",
+				"      Options: [--vFlag|-v VFLAG VFLAG [VFLAG]]",
+				"  wave ANY ANY [OPTIONS]",
+				"    Options: [--yourFlag|-y YOURFLAG YOURFLAG YOURFLAG]",
+			},
+		},
+		{
+			name: "includes required flags and constraints",
+			cmd: &TerminusCommand{
+				Args: []Arg{
+					StringArg("path", true, nil),
+				},
+				Flags: []Flag{
+					StringFlag("name", 'n', nil),
+					BoolFlag("json", 'j'),
+					BoolFlag("yaml", 'y'),
+				},
+				FlagConstraints: []FlagConstraint{
+					RequiredFlags("name"),
+					ExclusiveFlags("json", "yaml"),
+				},
+			},
+			want: []string{
+				"PATH --name|-n NAME [OPTIONS]",
+				"  Options: [--json|-j] [--yaml|-y]",
+				"  Constraints: --name is required",
+				"               --json, --yaml are mutually exclusive",
+			},
+		},
+		{
+			name: "wraps to terminal width",
+			cmd: &CommandBranch{
+				Subcommands: map[string]Command{
+					"create": &TerminusCommand{
+						Args: []Arg{
+							StringArg("name", true, nil),
+							StringListArg("tags", 0, UnboundedList, nil),
+						},
+						Flags: []Flag{
+							StringFlag("description", 'd', nil),
+							IntFlag("priority", 'p', nil),
+							BoolFlag("force", 'f'),
+						},
+					},
+					"delete":   &TerminusCommand{},
+					"describe": &TerminusCommand{},
+					"list":     &TerminusCommand{},
+				},
+			},
+			columns: "30",
+			want: []string{
+				"(create|delete|describe|list)",
+				" ...",
+				"  create NAME [TAGS ...]",
+				"         [OPTIONS]",
+				"    Options: [--description|-d DESCRIPTION]",
+				"             [--priority|-p PRIORITY]",
+				"             [--force|-f]",
+				"  delete",
+				"  describe",
+				"  list",
+			},
+		},
+		{
+			name: "omits hidden subcommands",
+			cmd:  branchCommand(NoopExecutor, &Completor{}).(*CommandBranch).Subcommands["info"],
+			want: []string{
+				"(add|delete|remove) ...",
+				"  add [ITEM]",
+				"  delete [ITEM]",
+				"  remove [ITEM]",
 			},
 		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
+			oldGetenv := getenv
+			getenv = func(key string) string {
+				if key == "COLUMNS" {
+					return test.columns
+				}
+				return ""
+			}
+			defer func() { getenv = oldGetenv }()
+
 			got := test.cmd.Usage()
 			if diff := cmp.Diff(test.want, got); diff != "" {
 				t.Errorf("command.Usage() returned diff (-want, +got):\n%s", diff)
@@ -361,14 +480,14 @@ func TestSet(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			gotArgsSet := map[string]bool{}
 			gotFlagsSet := map[string]bool{}
-			ex := func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+			ex := func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
 				for _, a := range test.cArgs {
 					gotArgsSet[a.Name()] = args[a.Name()].Provided()
 				}
 				for _, f := range test.cFlags {
 					gotFlagsSet[f.Name()] = flags[f.Name()].Provided()
 				}
-				return nil, true
+				return nil, nil
 			}
 
 			c := &TerminusCommand{
@@ -377,8 +496,8 @@ func TestSet(t *testing.T) {
 				Flags:    test.cFlags,
 			}
 			tcos := &TestCommandOS{}
-			if _, ok := Execute(tcos, c, test.args, nil); !ok {
-				t.Fatalf("commands.Execute(%s) failed: %v", test.args, tcos)
+			if _, err := Execute(tcos, c, test.args, nil); err != nil {
+				t.Fatalf("commands.Execute(%s) failed: %v", test.args, err)
 			}
 
 			if len(gotArgsSet) == 0 {
@@ -398,6 +517,21 @@ func TestSet(t *testing.T) {
 }
 
 func branchCommand(executor Executor, completor *Completor, opts ...ArgOpt) Command {
+	// namedSubcommand passes its name to the executor as the "subcommand" arg
+	// so tests can check which subcommand was executed.
+	namedSubcommand := func(name string) Command {
+		return &TerminusCommand{
+			Executor: func(cos CommandOS, args, flags map[string]*Value, oi *OptionInfo) (*ExecutorResponse, error) {
+				args["subcommand"] = StringValue(name)
+				return executor(cos, args, flags, oi)
+			},
+			Args: []Arg{
+				StringArg("item", false, &Completor{
+					SuggestionFetcher: &ListFetcher{Options: []string{name + "-one", name + "-two"}},
+				}),
+			},
+		}
+	}
 	return &CommandBranch{
 		Subcommands: map[string]Command{
 			"advanced": &CommandBranch{
@@ -571,6 +705,124 @@ func branchCommand(executor Executor, completor *Completor, opts ...ArgOpt) Comm
 					},
 				},
 			},
+			"inline": &TerminusCommand{
+				Executor: executor,
+				Args: []Arg{
+					StringListArg("files", 0, UnboundedList, nil),
+				},
+				Flags: []Flag{
+					BoolFlag("recursive", 'r'),
+					IntFlag("number", 'n', &Completor{
+						SuggestionFetcher: &ListFetcher{Options: []string{"5", "10"}},
+					}),
+					StringFlag("name", 0, nil),
+					StringListFlag("colors", 'c', 1, 2, &Completor{
+						Distinct:          true,
+						SuggestionFetcher: &ListFetcher{Options: []string{"blue", "green", "red"}},
+					}),
+				},
+			},
+			"terminator": &TerminusCommand{
+				Executor: executor,
+				Args: []Arg{
+					StringListArg("files", 0, UnboundedList, &Completor{
+						SuggestionFetcher: &ListFetcher{Options: []string{"--force", "one", "two"}},
+					}),
+				},
+				Flags: []Flag{
+					BoolFlag("force", 'f'),
+					StringListFlag("colors", 'c', 0, UnboundedList, &Completor{
+						SuggestionFetcher: &ListFetcher{Options: []string{"blue", "red"}},
+					}),
+				},
+			},
+			"switches": &TerminusCommand{
+				Executor: executor,
+				Args: []Arg{
+					StringListArg("files", 0, UnboundedList, nil),
+				},
+				Flags: []Flag{
+					BoolFlag("verbose", 'v'),
+					BoolFlag("force", 0),
+					StringFlag("no-force", 0, nil),
+				},
+			},
+			"defaults": &TerminusCommand{
+				Executor: executor,
+				Args: []Arg{
+					StringArg("greeting", true, nil),
+					StringArg("name", false, nil, Default(StringValue("world"))),
+				},
+				Flags: []Flag{
+					IntFlag("times", 't', nil, IntPositive(), Default(IntValue(3))),
+					BoolFlag("loud", 'l', Default(BoolValue(true))),
+					StringListFlag("tags", 0, 0, UnboundedList, nil, Default(StringListValue("a", "b"))),
+					FloatFlag("ratio", 'r', nil),
+				},
+			},
+			"fallbacks": &TerminusCommand{
+				Executor: executor,
+				Flags: []Flag{
+					IntFlag("times", 't', nil, EnvVar("TIMES"), ConfigKey("times"), Default(IntValue(1))),
+					StringListFlag("tags", 0, 1, UnboundedList, nil, EnvVar("TAGS"), ConfigKey("tags")),
+					BoolFlag("loud", 'l', EnvVar("LOUD"), ConfigKey("loud")),
+				},
+			},
+			"repeatable": &TerminusCommand{
+				Executor: executor,
+				Args: []Arg{
+					StringListArg("files", 0, UnboundedList, nil),
+				},
+				Flags: []Flag{
+					StringListFlag("tag", 't', 1, 0, &Completor{
+						Distinct:          true,
+						SuggestionFetcher: &ListFetcher{Options: []string{"a", "b", "c"}},
+					}, Repeatable()),
+					IntListFlag("size", 's', 1, 1, nil),
+					CountFlag("verbose", 'v', EnvVar("VERBOSE")),
+					BoolFlag("quiet", 'q'),
+				},
+			},
+			"matching": &CommandBranch{
+				PrefixMatching: true,
+				Subcommands: map[string]Command{
+					"add":      namedSubcommand("add"),
+					"delete":   namedSubcommand("delete"),
+					"describe": namedSubcommand("describe"),
+				},
+			},
+			"info": &CommandBranch{
+				PrefixMatching: true,
+				Subcommands: map[string]Command{
+					"add":    namedSubcommand("add"),
+					"debug":  namedSubcommand("debug"),
+					"delete": namedSubcommand("delete"),
+					"remove": namedSubcommand("remove"),
+				},
+				SubcommandInfo: map[string]*SubcommandInfo{
+					"add":    {Aliases: []string{"new", "create"}},
+					"debug":  {Hidden: true},
+					"delete": {Aliases: []string{"rm"}},
+					"remove": {Deprecated: `use "delete" instead`},
+				},
+			},
+			"cluster": &TerminusCommand{
+				Executor: executor,
+				Args: []Arg{
+					StringListArg("files", 0, UnboundedList, &Completor{
+						SuggestionFetcher: &ListFetcher{Options: []string{"one", "two"}},
+					}),
+				},
+				Flags: []Flag{
+					BoolFlag("recursive", 'r'),
+					BoolFlag("verbose", 'v'),
+					BoolFlag("all", 0),
+					IntFlag("number", 'n', &Completor{
+						SuggestionFetcher: &ListFetcher{Options: []string{"5", "10"}},
+					}),
+					StringFlag("message", 'm', nil),
+				},
+			},
 		},
 	}
 }
@@ -579,6 +831,8 @@ func TestExecute(t *testing.T) {
 	for _, test := range []struct {
 		name             string
 		args             []string
+		env              map[string]string
+		config           map[string][]string
 		ex               Executor
 		exResp           *ExecutorResponse
 		opts             []ArgOpt
@@ -587,6 +841,8 @@ func TestExecute(t *testing.T) {
 		wantStdout       []string
 		wantExecuteArgs  map[string]*Value
 		wantExecuteFlags map[string]*Value
+		wantProvided     map[string]bool
+		wantSources      map[string]ValueSource
 		wantOK           bool
 	}{
 		// Basic tests
@@ -609,6 +865,11 @@ func TestExecute(t *testing.T) {
 			args:       []string{"basic", "--state", "maine", "build", "one", "else", "too"},
 			wantStderr: []string{"extra unknown args ([else too])"},
 		},
+		{
+			name:       "prints errors with percent signs verbatim",
+			args:       []string{"basic", "--state", "maine", "build", "one", "50%d"},
+			wantStderr: []string{"extra unknown args ([50%d])"},
+		},
 		{
 			name:       "not enough positional arguments when partial list",
 			args:       []string{"intermediate", "--state", "maine", "one"},
@@ -634,9 +895,8 @@ func TestExecute(t *testing.T) {
 		},
 		{
 			name: "fails when CommandBranch defines executor fails",
-			ex: func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
-				cos.Stderr("bad news bears")
-				return nil, false
+			ex: func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
+				return nil, fmt.Errorf("bad news bears")
 			},
 			args:       []string{"advanced", "not", "registered"},
 			wantStderr: []string{"bad news bears"},
@@ -809,12 +1069,12 @@ func TestExecute(t *testing.T) {
 			want:   &ExecutorResponse{Executable: []string{"this", "was a", "success"}},
 		},
 		{
-			name: "fails when executor returns false",
+			name: "fails when bool executor returns false",
 			args: []string{"intermediate", "first", "2nd", "bronze"},
-			ex: func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+			ex: FromBoolExecutor(func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
 				cos.Stderr("this was a failure")
 				return nil, false
-			},
+			}),
 			wantStderr: []string{"this was a failure"},
 		},
 		// CommandBranch with terminus command
@@ -1573,185 +1833,881 @@ func TestExecute(t *testing.T) {
 				"req": FloatValue(1),
 			},
 		},
-		/* Useful comment for commenting out tests */
-	} {
-		t.Run(test.name, func(t *testing.T) {
-			var gotExecuteArgs map[string]*Value
-			var gotExecuteFlags map[string]*Value
-
-			ex := test.ex
-			if ex == nil {
-				// TODO: verify oi is correct
-				ex = func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
-					// Check length so we can consider empty to be the same as nil.
-					// That makes for cleaner test cases.
-					if len(args) > 0 {
-						gotExecuteArgs = args
-					}
-					if len(flags) > 0 {
-						gotExecuteFlags = flags
-					}
-					return test.exResp, true
-				}
-			}
-
-			cmd := branchCommand(ex, &Completor{}, test.opts...)
-
-			tcos := &TestCommandOS{}
-
-			got, ok := Execute(tcos, cmd, test.args, nil)
-			if ok != test.wantOK {
-				t.Errorf("commands.Execute(%v) returned %v for ok; want %v", test.args, ok, test.wantOK)
-			}
-
-			if diff := cmp.Diff(test.want, got); diff != "" {
-				t.Errorf("command.Execute(%v) returned diff (-want, +got):\n%s", test.args, diff)
-			}
-
-			if diff := cmp.Diff(test.wantStdout, tcos.GetStdout()); diff != "" {
-				t.Errorf("command.Execute(%v) produced stdout diff (-want, +got):\n%s", test.args, diff)
-			}
-			if diff := cmp.Diff(test.wantStderr, tcos.GetStderr()); diff != "" {
-				t.Errorf("command.Execute(%v) produced stderr diff (-want, +got):\n%s", test.args, diff)
-			}
-
-			if diff := cmp.Diff(test.wantExecuteArgs, gotExecuteArgs); diff != "" {
-				t.Errorf("command.Execute(%v) produced execute args diff (-want, +got):\n%s", test.args, diff)
-			}
-
-			if diff := cmp.Diff(test.wantExecuteFlags, gotExecuteFlags); diff != "" {
-				t.Errorf("command.Execute(%v) produced execute flags diff (-want, +got):\n%s", test.args, diff)
-			}
-		})
-	}
-}
-
-func TestCommandComplete(t *testing.T) {
-	// Note: this uses cmd.Command (as opposed to the package level function "Autocomplete").
-	// All more generic tests should go in "TestAutocomplete".
-	for _, test := range []struct {
-		name string
-		cmd  Command
-		args []string
-		want []string
-	}{
+		// Short flag cluster tests
 		{
-			name: "handles nil args",
-			cmd: &CommandBranch{
-				TerminusCommand: &TerminusCommand{},
-				Subcommands: map[string]Command{
-					"a": &TerminusCommand{},
-					"b": &CommandBranch{},
-					"c": &TerminusCommand{},
-				},
+			name:   "executes bool flag cluster",
+			args:   []string{"cluster", "-rv", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
 			},
-			want: []string{"a", "b", "c"},
-		},
-	} {
-		t.Run(test.name, func(t *testing.T) {
-			suggestions := test.cmd.Complete(test.args).Suggestions
-			sort.Strings(suggestions)
-			if diff := cmp.Diff(test.want, suggestions); diff != "" {
-				t.Errorf("Complete(%v) produced diff (-want, +got):\n%s", test.args, diff)
-			}
-		})
-	}
-}
-
-func TestAutocomplete(t *testing.T) {
-	for _, test := range []struct {
-		name              string
-		cmd               Command
-		args              []string
-		cursorIdx         int
-		distinct          bool
-		want              []string
-		fetchResp         []string
-		wantCompleteArgs  map[string]*Value
-		wantCompleteFlags map[string]*Value
-		wantValue         *Value
-	}{
-		// Basic tests
-		{
-			name: "nil arg gets predicted",
-			want: []string{
-				"advanced",
-				"basic",
-				"basically",
-				"beginner",
-				"dquo",
-				"ignore",
-				"intermediate",
-				"mw",
-				"prefixes",
-				"sometimes",
-				"squo",
-				"valueTypes",
-				"wave",
+			wantExecuteFlags: map[string]*Value{
+				"recursive": BoolValue(true),
+				"verbose":   BoolValue(true),
 			},
 		},
 		{
-			name: "empty arg gets predicted",
-			args: []string{""},
-			want: []string{
-				"advanced",
-				"basic",
-				"basically",
-				"beginner",
-				"dquo",
-				"ignore",
-				"intermediate",
-				"mw",
-				"prefixes",
-				"sometimes",
-				"squo",
-				"valueTypes",
-				"wave",
+			name:   "last flag in cluster takes value",
+			args:   []string{"cluster", "one", "-vn", "5", "two"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one", "two"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"verbose": BoolValue(true),
+				"number":  IntValue(5),
 			},
 		},
 		{
-			name: "partially complete arg gets multiple recommendations",
-			args: []string{"b"},
-			want: []string{"basic", "basically", "beginner"},
+			name:       "last flag in cluster requires value",
+			args:       []string{"cluster", "-rn"},
+			wantStderr: []string{`no argument provided for "number"`},
 		},
 		{
-			name: "partially complete arg gets multiple recommendations when a match is subset",
-			args: []string{"basic"},
-			want: []string{"basic", "basically"},
+			name:       "cluster with value flag before the end includes value",
+			args:       []string{"cluster", "-nr"},
+			wantStderr: []string{`argument should be an integer: strconv.Atoi: parsing "r": invalid syntax`},
 		},
 		{
-			name: "completes when exact macth with one option",
-			args: []string{"advanced"},
-			want: []string{"advanced"},
+			name:   "cluster with unknown flag is an arg",
+			args:   []string{"cluster", "-rx"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("-rx"),
+			},
 		},
 		{
-			name: "no complete when unknown arg",
-			args: []string{"unknown"},
+			name:   "doesn't expand flag value that looks like a cluster",
+			args:   []string{"cluster", "--message", "-rv"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"message": StringValue("-rv"),
+			},
 		},
 		{
-			name:      "empty second arg gets autocompleted",
-			args:      []string{"basic", ""},
-			fetchResp: []string{"build", "test", "try", "trying"},
-			want:      []string{"build", "test", "try", "trying"},
-			wantValue: StringListValue(""),
-			wantCompleteArgs: map[string]*Value{
-				"val_1": StringListValue(""),
+			name:   "help flag as flag value",
+			args:   []string{"cluster", "--message", "-h"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"message": StringValue("-h"),
 			},
 		},
 		{
-			name:      "partially complete second arg gets autocompleted",
-			args:      []string{"basic", "t"},
-			fetchResp: []string{"build", "test", "try", "trying"},
-			want:      []string{"test", "try", "trying"},
-			wantValue: StringListValue("t"),
-			wantCompleteArgs: map[string]*Value{
-				"val_1": StringListValue("t"),
+			name:   "help flag as value of clustered flag",
+			args:   []string{"cluster", "-rm", "--help", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"recursive": BoolValue(true),
+				"message":   StringValue("--help"),
 			},
 		},
 		{
-			name: "no autocomplete when run out of defined args",
-			args: []string{"basic", "one", "two", ""},
-		},
+			name:   "help flag after flag value",
+			args:   []string{"cluster", "-m", "hello", "-h"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: cluster [FILES ...] [OPTIONS]",
+				"",
+				"Arguments:",
+				"  FILES  StringList (0+)",
+				"",
+				"Options:",
+				"  --recursive, -r",
+				"  --verbose, -v",
+				"  --all",
+				"  --number, -n     Int",
+				"  --message, -m    String",
+			},
+		},
+		{
+			name:   "doesn't expand short flag value that looks like a cluster",
+			args:   []string{"cluster", "-m", "-rv", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"message": StringValue("-rv"),
+			},
+		},
+		// Inline flag value tests
+		{
+			name:   "long flag with value",
+			args:   []string{"inline", "--number=5", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"number": IntValue(5),
+			},
+		},
+		{
+			name:   "long flag with empty value",
+			args:   []string{"inline", "--name="},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"name": StringValue(""),
+			},
+		},
+		{
+			name:   "value includes equals sign",
+			args:   []string{"inline", "--name=a=b"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"name": StringValue("a=b"),
+			},
+		},
+		{
+			name:   "short flag with value",
+			args:   []string{"inline", "-n5", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"number": IntValue(5),
+			},
+		},
+		{
+			name:   "short flag with value after bool cluster",
+			args:   []string{"inline", "-rn10"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"recursive": BoolValue(true),
+				"number":    IntValue(10),
+			},
+		},
+		{
+			name:   "list flag with comma separated values",
+			args:   []string{"inline", "--colors=red,blue", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"colors": StringListValue("red", "blue"),
+			},
+		},
+		{
+			name:   "short list flag with comma separated values",
+			args:   []string{"inline", "-cred,blue", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"colors": StringListValue("red", "blue"),
+			},
+		},
+		{
+			name:       "too many list values",
+			args:       []string{"inline", "--colors=red,blue,green,red"},
+			wantStderr: []string{`too many values provided for flag "colors": [red]`},
+		},
+		{
+			name:       "bool flag with invalid value",
+			args:       []string{"inline", "--recursive=yes"},
+			wantStderr: []string{`argument should be a bool: "yes"`},
+		},
+		{
+			name:       "invalid value",
+			args:       []string{"inline", "--number=five"},
+			wantStderr: []string{`argument should be an integer: strconv.Atoi: parsing "five": invalid syntax`},
+		},
+		{
+			name:   "unknown flag is an arg",
+			args:   []string{"inline", "--other=5"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("--other=5"),
+			},
+		},
+		// Flag terminator tests
+		{
+			name:   "flag names after terminator are args",
+			args:   []string{"terminator", "one", "--", "--force", "-f"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one", "--force", "-f"),
+			},
+		},
+		{
+			name:   "flags before terminator are parsed",
+			args:   []string{"terminator", "-f", "one", "--", "-c"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one", "-c"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"force": BoolValue(true),
+			},
+		},
+		{
+			name:   "list flag stops at terminator",
+			args:   []string{"terminator", "--colors", "red", "blue", "--", "two"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("two"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"colors": StringListValue("red", "blue"),
+			},
+		},
+		{
+			name:   "only first terminator is removed",
+			args:   []string{"terminator", "--", "--"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("--"),
+			},
+		},
+		{
+			name:   "terminator with no args",
+			args:   []string{"terminator", "-f", "--"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"force": BoolValue(true),
+			},
+		},
+		{
+			name:   "help flags after terminator are args",
+			args:   []string{"terminator", "--", "-h", "--help"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("-h", "--help"),
+			},
+		},
+		{
+			name:   "help flag before terminator prints help",
+			args:   []string{"terminator", "-h", "--", "one"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: terminator [FILES ...] [OPTIONS]",
+				"",
+				"Arguments:",
+				"  FILES  StringList (0+)",
+				"",
+				"Options:",
+				"  --force, -f",
+				"  --colors, -c  StringList (0+)",
+			},
+		},
+		// Bool flag value tests
+		{
+			name:   "negated flag",
+			args:   []string{"switches", "--no-verbose"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"verbose": BoolValue(false),
+			},
+			wantProvided: map[string]bool{
+				"verbose": true,
+			},
+		},
+		{
+			name:   "last flag wins",
+			args:   []string{"switches", "-v", "--no-verbose", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"verbose": BoolValue(false),
+			},
+			wantProvided: map[string]bool{
+				"verbose": true,
+			},
+		},
+		{
+			name:   "explicit false",
+			args:   []string{"switches", "--verbose=false"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"verbose": BoolValue(false),
+			},
+			wantProvided: map[string]bool{
+				"verbose": true,
+			},
+		},
+		{
+			name:   "explicit true",
+			args:   []string{"switches", "--verbose=T"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"verbose": BoolValue(true),
+			},
+			wantProvided: map[string]bool{
+				"verbose": true,
+			},
+		},
+		{
+			name:   "bool flag doesn't consume next arg",
+			args:   []string{"switches", "--verbose", "false"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("false"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"verbose": BoolValue(true),
+			},
+			wantProvided: map[string]bool{
+				"verbose": true,
+			},
+		},
+		{
+			name:   "bool flag not provided",
+			args:   []string{"switches", "one"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantProvided: map[string]bool{
+				"verbose": false,
+			},
+		},
+		{
+			name:       "negated flag with value",
+			args:       []string{"switches", "--no-verbose=true"},
+			wantStderr: []string{`flag "no-verbose" does not take a value`},
+		},
+		{
+			name:       "invalid bool flag value",
+			args:       []string{"switches", "--verbose=maybe"},
+			wantStderr: []string{`argument should be a bool: "maybe"`},
+		},
+		{
+			name:   "explicit flag takes precedence over negation",
+			args:   []string{"switches", "--no-force", "please"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"no-force": StringValue("please"),
+			},
+			wantProvided: map[string]bool{
+				"force": false,
+			},
+		},
+		// Default value tests
+		{
+			name:   "sets default values",
+			args:   []string{"defaults", "hello"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"greeting": StringValue("hello"),
+				"name":     StringValue("world"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"times": IntValue(3),
+				"loud":  BoolValue(true),
+				"tags":  StringListValue("a", "b"),
+			},
+			wantProvided: map[string]bool{
+				"greeting": true,
+				"name":     false,
+				"times":    false,
+				"loud":     false,
+				"tags":     false,
+				"ratio":    false,
+			},
+		},
+		{
+			name:   "provided values override defaults",
+			args:   []string{"defaults", "hello", "there", "-t", "5", "--no-loud", "-r", "0.5", "--tags", "c"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"greeting": StringValue("hello"),
+				"name":     StringValue("there"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"times": IntValue(5),
+				"loud":  BoolValue(false),
+				"tags":  StringListValue("c"),
+				"ratio": FloatValue(0.5),
+			},
+			wantProvided: map[string]bool{
+				"greeting": true,
+				"name":     true,
+				"times":    true,
+				"loud":     true,
+				"tags":     true,
+				"ratio":    true,
+			},
+		},
+		// Flag fallback tests
+		{
+			name:   "uses default without env or config",
+			args:   []string{"fallbacks"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"times": IntValue(1),
+			},
+			wantSources: map[string]ValueSource{
+				"times": DefaultSource,
+			},
+		},
+		{
+			name: "uses config",
+			args: []string{"fallbacks"},
+			config: map[string][]string{
+				"times": {"2"},
+				"tags":  {"a", "b"},
+				"loud":  {"true"},
+			},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"times": IntValue(2),
+				"tags":  StringListValue("a", "b"),
+				"loud":  BoolValue(true),
+			},
+			wantSources: map[string]ValueSource{
+				"times": ConfigSource,
+				"tags":  ConfigSource,
+				"loud":  ConfigSource,
+			},
+		},
+		{
+			name: "env takes precedence over config",
+			args: []string{"fallbacks"},
+			env: map[string]string{
+				"TIMES": "3",
+				"TAGS":  "c,d",
+			},
+			config: map[string][]string{
+				"times": {"2"},
+				"tags":  {"a", "b"},
+			},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"times": IntValue(3),
+				"tags":  StringListValue("c", "d"),
+			},
+			wantSources: map[string]ValueSource{
+				"times": EnvSource,
+				"tags":  EnvSource,
+			},
+		},
+		{
+			name: "command line takes precedence over env",
+			args: []string{"fallbacks", "-t", "4", "--no-loud"},
+			env: map[string]string{
+				"TIMES": "3",
+				"LOUD":  "true",
+			},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"times": IntValue(4),
+				"loud":  BoolValue(false),
+			},
+			wantSources: map[string]ValueSource{
+				"times": CommandLineSource,
+				"loud":  CommandLineSource,
+			},
+		},
+		{
+			name: "invalid env value",
+			args: []string{"fallbacks"},
+			env: map[string]string{
+				"TIMES": "three",
+			},
+			wantStderr: []string{`invalid value for environment variable TIMES: argument should be an integer: strconv.Atoi: parsing "three": invalid syntax`},
+		},
+		{
+			name: "invalid config value",
+			args: []string{"fallbacks"},
+			config: map[string][]string{
+				"times": {"1", "2"},
+			},
+			wantStderr: []string{`invalid value for config key "times": too many values: [2]`},
+		},
+		{
+			name: "invalid bool config value",
+			args: []string{"fallbacks"},
+			config: map[string][]string{
+				"loud": {"sure"},
+			},
+			wantStderr: []string{`invalid value for config key "loud": argument should be a bool: "sure"`},
+		},
+		// Repeatable flag tests
+		{
+			name:   "repeatable flag accumulates values",
+			args:   []string{"repeatable", "--tag", "a", "one", "-t", "b", "--tag=c"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"tag": StringListValue("a", "b", "c"),
+			},
+		},
+		{
+			name:   "non-repeatable flag uses last occurrence",
+			args:   []string{"repeatable", "--size", "1", "2", "-s", "3"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"size": IntListValue(3),
+			},
+		},
+		{
+			name:   "counts flag occurrences",
+			args:   []string{"repeatable", "-v", "one", "--verbose", "-v"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"files": StringListValue("one"),
+			},
+			wantExecuteFlags: map[string]*Value{
+				"verbose": IntValue(3),
+			},
+		},
+		{
+			name:   "counts flag in cluster",
+			args:   []string{"repeatable", "-vqvv"},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"verbose": IntValue(3),
+				"quiet":   BoolValue(true),
+			},
+		},
+		{
+			name: "count from environment variable",
+			args: []string{"repeatable"},
+			env: map[string]string{
+				"VERBOSE": "2",
+			},
+			wantOK: true,
+			wantExecuteFlags: map[string]*Value{
+				"verbose": IntValue(2),
+			},
+		},
+		{
+			name:       "count flag doesn't take a value",
+			args:       []string{"repeatable", "--verbose=2"},
+			wantStderr: []string{`too many values provided for flag "verbose": [2]`},
+		},
+		// Prefix matching tests
+		{
+			name:   "exact match",
+			args:   []string{"matching", "delete"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"subcommand": StringValue("delete"),
+			},
+		},
+		{
+			name:   "unique prefix",
+			args:   []string{"matching", "del"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"subcommand": StringValue("delete"),
+			},
+		},
+		{
+			name:   "single letter prefix",
+			args:   []string{"matching", "a", "item"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"item":       StringValue("item"),
+				"subcommand": StringValue("add"),
+			},
+		},
+		{
+			name:       "ambiguous prefix",
+			args:       []string{"matching", "de"},
+			wantStderr: []string{`unknown subcommand and no terminus command defined (did you mean "delete" or "describe"?)`},
+		},
+		{
+			name:       "prefix without prefix matching",
+			args:       []string{"matchin"},
+			wantStderr: []string{`unknown subcommand and no terminus command defined (did you mean "matching"?)`},
+		},
+		// Subcommand info tests
+		{
+			name:   "executes alias",
+			args:   []string{"info", "rm"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"subcommand": StringValue("delete"),
+			},
+		},
+		{
+			name:   "executes prefix of alias",
+			args:   []string{"info", "cr"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"subcommand": StringValue("add"),
+			},
+		},
+		{
+			name:   "prefix of name and alias of same subcommand isn't ambiguous",
+			args:   []string{"info", "n"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"subcommand": StringValue("add"),
+			},
+		},
+		{
+			name:   "executes hidden subcommand",
+			args:   []string{"info", "debug"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"subcommand": StringValue("debug"),
+			},
+		},
+		{
+			name:       "doesn't prefix match hidden subcommand",
+			args:       []string{"info", "deb"},
+			wantStderr: []string{"unknown subcommand and no terminus command defined"},
+		},
+		{
+			name:   "executes deprecated subcommand with warning",
+			args:   []string{"info", "remove", "item"},
+			wantOK: true,
+			wantExecuteArgs: map[string]*Value{
+				"item":       StringValue("item"),
+				"subcommand": StringValue("remove"),
+			},
+			wantStderr: []string{
+				`subcommand "remove" is deprecated: use "delete" instead`,
+			},
+		},
+		/* Useful comment for commenting out tests */
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var gotExecuteArgs map[string]*Value
+			var gotExecuteFlags map[string]*Value
+			var gotProvided map[string]bool
+			var gotSources map[string]ValueSource
+
+			ex := test.ex
+			if ex == nil {
+				// TODO: verify oi is correct
+				ex = func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
+					// Check length so we can consider empty to be the same as nil.
+					// That makes for cleaner test cases.
+					if len(args) > 0 {
+						gotExecuteArgs = args
+					}
+					if len(flags) > 0 {
+						gotExecuteFlags = flags
+					}
+					for name := range test.wantProvided {
+						v, ok := flags[name]
+						if !ok {
+							v = args[name]
+						}
+						if gotProvided == nil {
+							gotProvided = map[string]bool{}
+						}
+						gotProvided[name] = v.Provided()
+					}
+					if test.wantSources != nil {
+						gotSources = map[string]ValueSource{}
+						for name, v := range flags {
+							gotSources[name] = v.Source()
+						}
+					}
+					return test.exResp, nil
+				}
+			}
+
+			cmd := branchCommand(ex, &Completor{}, test.opts...)
+
+			oldGetenv := getenv
+			getenv = func(key string) string { return test.env[key] }
+			defer func() { getenv = oldGetenv }()
+
+			var oi *OptionInfo
+			if test.config != nil {
+				oi = &OptionInfo{Config: test.config}
+			}
+
+			tcos := &TestCommandOS{}
+
+			got, err := Execute(tcos, cmd, test.args, oi)
+			if ok := err == nil; ok != test.wantOK {
+				t.Errorf("commands.Execute(%v) returned %v for ok; want %v", test.args, ok, test.wantOK)
+			}
+
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("command.Execute(%v) returned diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			if diff := cmp.Diff(test.wantStdout, tcos.GetStdout()); diff != "" {
+				t.Errorf("command.Execute(%v) produced stdout diff (-want, +got):\n%s", test.args, diff)
+			}
+			if diff := cmp.Diff(test.wantStderr, tcos.GetStderr()); diff != "" {
+				t.Errorf("command.Execute(%v) produced stderr diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			if diff := cmp.Diff(test.wantExecuteArgs, gotExecuteArgs); diff != "" {
+				t.Errorf("command.Execute(%v) produced execute args diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			if diff := cmp.Diff(test.wantExecuteFlags, gotExecuteFlags); diff != "" {
+				t.Errorf("command.Execute(%v) produced execute flags diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			if diff := cmp.Diff(test.wantProvided, gotProvided); diff != "" {
+				t.Errorf("command.Execute(%v) produced provided diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			if diff := cmp.Diff(test.wantSources, gotSources); diff != "" {
+				t.Errorf("command.Execute(%v) produced sources diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestCommandComplete(t *testing.T) {
+	// Note: this uses cmd.Command (as opposed to the package level function "Autocomplete").
+	// All more generic tests should go in "TestAutocomplete".
+	for _, test := range []struct {
+		name string
+		cmd  Command
+		args []string
+		want []string
+	}{
+		{
+			name: "handles nil args",
+			cmd: &CommandBranch{
+				TerminusCommand: &TerminusCommand{},
+				Subcommands: map[string]Command{
+					"a": &TerminusCommand{},
+					"b": &CommandBranch{},
+					"c": &TerminusCommand{},
+				},
+			},
+			want: []string{"a", "b", "c"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			completion, err := test.cmd.Complete(test.args)
+			if err != nil {
+				t.Fatalf("Complete(%v) returned error: %v", test.args, err)
+			}
+			suggestions := completion.Suggestions
+			sort.Strings(suggestions)
+			if diff := cmp.Diff(test.want, suggestions); diff != "" {
+				t.Errorf("Complete(%v) produced diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestAutocomplete(t *testing.T) {
+	for _, test := range []struct {
+		name              string
+		cmd               Command
+		args              []string
+		cursorIdx         int
+		distinct          bool
+		want              []string
+		fetchResp         []string
+		wantCompleteArgs  map[string]*Value
+		wantCompleteFlags map[string]*Value
+		wantValue         *Value
+	}{
+		// Basic tests
+		{
+			name: "nil arg gets predicted",
+			want: []string{
+				"advanced",
+				"basic",
+				"basically",
+				"beginner",
+				"cluster",
+				"defaults",
+				"dquo",
+				"fallbacks",
+				"ignore",
+				"info",
+				"inline",
+				"intermediate",
+				"matching",
+				"mw",
+				"prefixes",
+				"repeatable",
+				"sometimes",
+				"squo",
+				"switches",
+				"terminator",
+				"valueTypes",
+				"wave",
+			},
+		},
+		{
+			name: "empty arg gets predicted",
+			args: []string{""},
+			want: []string{
+				"advanced",
+				"basic",
+				"basically",
+				"beginner",
+				"cluster",
+				"defaults",
+				"dquo",
+				"fallbacks",
+				"ignore",
+				"info",
+				"inline",
+				"intermediate",
+				"matching",
+				"mw",
+				"prefixes",
+				"repeatable",
+				"sometimes",
+				"squo",
+				"switches",
+				"terminator",
+				"valueTypes",
+				"wave",
+			},
+		},
+		{
+			name: "partially complete arg gets multiple recommendations",
+			args: []string{"b"},
+			want: []string{"basic", "basically", "beginner"},
+		},
+		{
+			name: "partially complete arg gets multiple recommendations when a match is subset",
+			args: []string{"basic"},
+			want: []string{"basic", "basically"},
+		},
+		{
+			name: "completes when exact macth with one option",
+			args: []string{"advanced"},
+			want: []string{"advanced"},
+		},
+		{
+			name: "no complete when unknown arg",
+			args: []string{"unknown"},
+		},
+		{
+			name:      "empty second arg gets autocompleted",
+			args:      []string{"basic", ""},
+			fetchResp: []string{"build", "test", "try", "trying"},
+			want:      []string{"build", "test", "try", "trying"},
+			wantValue: StringListValue(""),
+			wantCompleteArgs: map[string]*Value{
+				"val_1": StringListValue(""),
+			},
+		},
+		{
+			name:      "partially complete second arg gets autocompleted",
+			args:      []string{"basic", "t"},
+			fetchResp: []string{"build", "test", "try", "trying"},
+			want:      []string{"test", "try", "trying"},
+			wantValue: StringListValue("t"),
+			wantCompleteArgs: map[string]*Value{
+				"val_1": StringListValue("t"),
+			},
+		},
+		{
+			name: "no autocomplete when run out of defined args",
+			args: []string{"basic", "one", "two", ""},
+		},
 		{
 			name:      "works with more than two argument groups",
 			args:      []string{"basic", "build", "o"},
@@ -2606,6 +3562,171 @@ func TestAutocomplete(t *testing.T) {
 			args: []string{"valueTypes", "bool", "maybe", ""},
 			want: []string{"f", "false", "t", "true"},
 		},
+		// Short flag cluster tests
+		{
+			name: "suggests remaining short flags after cluster",
+			args: []string{"cluster", "-rv"},
+			want: []string{"-rv", "-rvm", "-rvn"},
+		},
+		{
+			name: "completes single short flag",
+			args: []string{"cluster", "-r"},
+			want: []string{"-r"},
+		},
+		{
+			name: "doesn't suggest flags used earlier",
+			args: []string{"cluster", "-n", "5", "one", "-rv"},
+			want: []string{"-rv", "-rvm"},
+		},
+		{
+			name: "doesn't suggest flags after value flag",
+			args: []string{"cluster", "-rn"},
+			want: []string{"-rn"},
+		},
+		{
+			name: "completes value of clustered flag",
+			args: []string{"cluster", "-rn", ""},
+			want: []string{"10", "5"},
+		},
+		{
+			name: "completes args after cluster",
+			args: []string{"cluster", "-rv", "t"},
+			want: []string{"two"},
+		},
+		{
+			name: "doesn't expand flag value that looks like a cluster",
+			args: []string{"cluster", "--message", "-rn", ""},
+			want: []string{"one", "two"},
+		},
+		{
+			name: "doesn't expand short flag value that looks like a cluster",
+			args: []string{"cluster", "-m", "-rn", "t"},
+			want: []string{"two"},
+		},
+		// Inline flag value tests
+		{
+			name: "completes long flag value",
+			args: []string{"inline", "--number="},
+			want: []string{"--number=10", "--number=5"},
+		},
+		{
+			name: "completes partial long flag value",
+			args: []string{"inline", "--number=1"},
+			want: []string{"--number=10"},
+		},
+		{
+			name: "completes short flag value",
+			args: []string{"inline", "-rn1"},
+			want: []string{"-rn10"},
+		},
+		{
+			name: "completes last list flag value",
+			args: []string{"inline", "--colors=red,"},
+			want: []string{"--colors=red,blue", "--colors=red,green"},
+		},
+		{
+			name: "completes flag names after inline flag",
+			args: []string{"inline", "--colors=red", "-"},
+			want: []string{"--colors", "--name", "--number", "--recursive"},
+		},
+		{
+			name: "completes value after bash splits at equals sign",
+			args: []string{"inline", "--number", "="},
+			want: []string{"=10", "=5"},
+		},
+		{
+			name: "completes partial value after bash splits at equals sign",
+			args: []string{"inline", "--colors", "=", "red,g"},
+			want: []string{"red,green"},
+		},
+		// Flag terminator tests
+		{
+			name: "completes flag names before terminator",
+			args: []string{"terminator", "--f"},
+			want: []string{"--force"},
+		},
+		{
+			name: "completes args after terminator",
+			args: []string{"terminator", "--", "--f"},
+			want: []string{"--force"},
+		},
+		{
+			name: "completes args instead of flag values after terminator",
+			args: []string{"terminator", "--colors", "red", "--", ""},
+			want: []string{"--force", "one", "two"},
+		},
+		{
+			name: "doesn't complete flag names after terminator",
+			args: []string{"terminator", "one", "--", "-"},
+			want: []string{"--force"},
+		},
+		// Bool flag value tests
+		{
+			name: "completes bool values",
+			args: []string{"switches", "--verbose="},
+			want: []string{"--verbose=f", "--verbose=false", "--verbose=t", "--verbose=true"},
+		},
+		{
+			name: "completes partial bool value",
+			args: []string{"switches", "--verbose=fa"},
+			want: []string{"--verbose=false"},
+		},
+		{
+			name: "doesn't complete negated flag value",
+			args: []string{"switches", "--no-verbose="},
+		},
+		// Repeatable flag tests
+		{
+			name: "doesn't suggest values from earlier occurrences",
+			args: []string{"repeatable", "--tag", "a", "-t", "c", "--tag", ""},
+			want: []string{"b"},
+		},
+		{
+			name: "suggests count flag again in cluster",
+			args: []string{"repeatable", "-vv"},
+			want: []string{"-vv", "-vvq", "-vvs", "-vvt", "-vvv"},
+		},
+		// Prefix matching tests
+		{
+			name: "completes subcommand names",
+			args: []string{"matching", "de"},
+			want: []string{"delete", "describe"},
+		},
+		{
+			name: "completes args of prefix matched subcommand",
+			args: []string{"matching", "del", ""},
+			want: []string{"delete-one", "delete-two"},
+		},
+		{
+			name: "doesn't complete args of ambiguous prefix",
+			args: []string{"matching", "de", ""},
+		},
+		// Subcommand info tests
+		{
+			name: "completes visible subcommands once",
+			args: []string{"info", ""},
+			want: []string{"add", "delete"},
+		},
+		{
+			name: "completes alias if name doesn't match",
+			args: []string{"info", "r"},
+			want: []string{"rm"},
+		},
+		{
+			name: "completes name over alias",
+			args: []string{"info", "de"},
+			want: []string{"delete"},
+		},
+		{
+			name: "completes args of alias",
+			args: []string{"info", "rm", ""},
+			want: []string{"delete-one", "delete-two"},
+		},
+		{
+			name: "completes args of hidden subcommand",
+			args: []string{"info", "debug", ""},
+			want: []string{"debug-one", "debug-two"},
+		},
 		/* Useful comment for commenting out tests */
 	} {
 		t.Run(test.name, func(t *testing.T) {
@@ -2617,7 +3738,15 @@ func TestAutocomplete(t *testing.T) {
 				SuggestionFetcher: fetcher,
 			}
 
-			got := Autocomplete(branchCommand(NoopExecutor, completor), test.args, test.cursorIdx)
+			cmd := test.cmd
+			if cmd == nil {
+				cmd = branchCommand(NoopExecutor, completor)
+			}
+
+			got, err := Autocomplete(cmd, test.args, test.cursorIdx)
+			if err != nil {
+				t.Fatalf("command.Autocomplete(%v, %d) returned error: %v", test.args, test.cursorIdx, err)
+			}
 			if len(got) == 0 {
 				got = nil
 			}
@@ -2647,7 +3776,7 @@ type testFetcher struct {
 	resp     []string
 }
 
-func (tf *testFetcher) Fetch(value *Value, args, flags map[string]*Value) *Completion {
+func (tf *testFetcher) Fetch(value *Value, args, flags map[string]*Value) (*Completion, error) {
 	// Check length so we can consider empty to be the same as nil.
 	// That makes for cleaner test cases.
 	if value != nil && value.Length() > 0 {
@@ -2662,7 +3791,7 @@ func (tf *testFetcher) Fetch(value *Value, args, flags map[string]*Value) *Compl
 
 	return &Completion{
 		Suggestions: tf.resp,
-	}
+	}, nil
 }
 
 // Test to get 100% coverage
@@ -2678,8 +3807,8 @@ func TestMiscellaneous(t *testing.T) {
 			"f4": StringListValue("4", "56"),
 		}
 
-		if resp, ok := NoopExecutor(nil, args, flags, nil); resp != nil && !ok {
-			t.Errorf("Expected NoopExecutor to return (nil, true); got (%v, %v)", resp, ok)
+		if resp, err := NoopExecutor(nil, args, flags, nil); resp != nil || err != nil {
+			t.Errorf("Expected NoopExecutor to return (nil, nil); got (%v, %v)", resp, err)
 		}
 	})
 
@@ -2692,6 +3821,128 @@ func TestMiscellaneous(t *testing.T) {
 		fs := map[string]*Value{
 			"hey": StringListValue("o", "o"),
 		}
-		_ = c.Complete("yo", v, as, fs)
+		_, _ = c.Complete("yo", v, as, fs)
 	})
 }
+
+func TestInvalidDefaultValues(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		define func()
+		want   string
+	}{
+		{
+			name:   "default with wrong type",
+			define: func() { IntArg("count", false, nil, Default(StringValue("three"))) },
+			want:   `default value for "count" has type String; expected Int`,
+		},
+		{
+			name:   "default fails validation",
+			define: func() { IntFlag("count", 'c', nil, IntPositive(), Default(IntValue(-1))) },
+			want:   `invalid default value for "count": validation failed: [IntPositive] value isn't positive`,
+		},
+		{
+			name:   "list default with wrong type",
+			define: func() { StringListFlag("names", 'n', 0, 1, nil, Default(StringValue("a"))) },
+			want:   `default value for "names" has type String; expected StringList`,
+		},
+		{
+			name:   "bool default with wrong type",
+			define: func() { BoolFlag("loud", 'l', Default(IntValue(1))) },
+			want:   `default value for "loud" has type Int; expected Bool`,
+		},
+		{
+			name:   "default for required arg",
+			define: func() { StringArg("name", true, nil, Default(StringValue("world"))) },
+			want:   `default value for "name" can't be used since the argument is required`,
+		},
+		{
+			name:   "default for required list arg",
+			define: func() { StringListArg("names", 1, 2, nil, Default(StringListValue("a"))) },
+			want:   `default value for "names" can't be used since the argument is required`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				if diff := cmp.Diff(test.want, r); diff != "" {
+					t.Errorf("defining argument produced panic diff (-want, +got):\n%s", diff)
+				}
+			}()
+			test.define()
+		})
+	}
+}
+
+func TestCompletionDescriptions(t *testing.T) {
+	cmd := &CommandBranch{
+		Subcommands: map[string]Command{
+			"add": &TerminusCommand{
+				Description: "Adds an item.",
+				Flags: []Flag{
+					BoolFlag("force", 'f', Description("Overwrites existing items.")),
+					StringFlag("name", 'n', nil),
+				},
+			},
+			"list": &CommandBranch{
+				TerminusCommand: &TerminusCommand{
+					Description: "Lists items.",
+				},
+			},
+			"remove": &TerminusCommand{},
+		},
+		SubcommandInfo: map[string]*SubcommandInfo{
+			"add": {Aliases: []string{"new"}},
+		},
+	}
+
+	for _, test := range []struct {
+		name    string
+		args    []string
+		want    []string
+		wantZsh []string
+	}{
+		{
+			name:    "describes subcommands",
+			args:    []string{""},
+			want:    []string{"add", "list", "remove"},
+			wantZsh: []string{"", "add:Adds an item.", "list:Lists items.", "remove"},
+		},
+		{
+			name:    "describes aliases",
+			args:    []string{"n"},
+			want:    []string{"new"},
+			wantZsh: []string{"", "new:Adds an item."},
+		},
+		{
+			name:    "describes flags",
+			args:    []string{"add", "-"},
+			want:    []string{"--force", "--name"},
+			wantZsh: []string{"", "--force:Overwrites existing items.", "--name"},
+		},
+		{
+			name:    "describes short flags",
+			args:    []string{"add", "-f"},
+			want:    []string{"-f"},
+			wantZsh: []string{"", "-f:Overwrites existing items."},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Autocomplete(cmd, test.args, len(test.args))
+			if err != nil {
+				t.Fatalf("Autocomplete(%v) returned error: %v", test.args, err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Autocomplete(%v) returned diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			gotZsh, err := ZshAutocomplete(cmd, test.args, len(test.args))
+			if err != nil {
+				t.Fatalf("ZshAutocomplete(%v) returned error: %v", test.args, err)
+			}
+			if diff := cmp.Diff(test.wantZsh, gotZsh); diff != "" {
+				t.Errorf("ZshAutocomplete(%v) returned diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package commands
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -51,7 +53,10 @@ func TestCompletors(t *testing.T) {
 					StringListArg("test", 2, 5, test.c),
 				},
 			}
-			got := Autocomplete(cmd, test.args, 0)
+			got, err := Autocomplete(cmd, test.args, 0)
+			if err != nil {
+				t.Fatalf("Autocomplete(%v, %v) returned error: %v", cmd, test.args, err)
+			}
 			if diff := cmp.Diff(test.want, got); diff != "" {
 				t.Errorf("Autocomplete(%v, %v) returned diff (-want, +got):\n%s", cmd, test.args, diff)
 			}
@@ -60,6 +65,11 @@ func TestCompletors(t *testing.T) {
 }
 
 func TestFetchers(t *testing.T) {
+	// Empty directories aren't tracked by git.
+	if err := os.MkdirAll(filepath.Join("testing", "empty"), 0755); err != nil {
+		t.Fatalf("failed to create empty directory: %v", err)
+	}
+
 	for _, test := range []struct {
 		name          string
 		f             Fetcher
@@ -69,6 +79,7 @@ func TestFetchers(t *testing.T) {
 		stringArg     bool
 		commandBranch bool
 		want          []string
+		wantErr       string
 	}{
 		{
 			name: "noop fetcher returns nil",
@@ -93,12 +104,11 @@ func TestFetchers(t *testing.T) {
 		},
 		// FileFetcher tests
 		{
-			name:   "file fetcher returns nil if failure fetching current directory",
-			f:      &FileFetcher{},
-			absErr: fmt.Errorf("failed to fetch directory"),
+			name:    "file fetcher returns error if failure fetching current directory",
+			f:       &FileFetcher{},
+			absErr:  fmt.Errorf("failed to fetch directory"),
+			wantErr: "failed to fetch suggestions: failed to get absolute path: failed to fetch directory",
 		},
-		// TODO: automatically create the empty directory
-		// at beginning of this test (since not tracked by git).
 		{
 			name: "file fetcher handles empty directory",
 			f:    &FileFetcher{},
@@ -112,18 +122,42 @@ func TestFetchers(t *testing.T) {
 				"aliaser_test.go",
 				"arg_options.go",
 				"arg_types.go",
+				"bash.go",
+				"bash_test.go",
 				"commands.go",
 				"commands_test.go",
 				"completor_test.go",
 				"completors.go",
+				"errors.go",
+				"errors_test.go",
+				"fish.go",
+				"fish_test.go",
+				"flag_constraints.go",
+				"flag_constraints_test.go",
 				"flag_types.go",
+				"help.go",
+				"help_test.go",
+				"man.go",
+				"man_test.go",
+				"markdown.go",
+				"markdown_test.go",
 				"new_arg_types.go",
 				"README.md",
+				"setup.go",
+				"setup_test.go",
+				"shell_actions.go",
+				"shell_actions_test.go",
+				"trace.go",
+				"trace_test.go",
+				"usage.go",
+				"usage_test.go",
 				"testing/",
 				"value.proto",
 				"value/",
 				"value_test.go",
 				"values.go",
+				"zsh.go",
+				"zsh_test.go",
 				" ",
 			},
 		},
@@ -147,10 +181,11 @@ func TestFetchers(t *testing.T) {
 			},
 		},
 		{
-			name: "file fetcher returns nil if failure listing directory",
+			name: "file fetcher returns error if failure listing directory",
 			f: &FileFetcher{
 				Directory: "does/not/exist",
 			},
+			wantErr: "failed to fetch suggestions: failed to read dir: open ",
 		},
 		{
 			name: "file fetcher returns files in the specified directory",
@@ -672,7 +707,14 @@ func TestFetchers(t *testing.T) {
 			} else {
 				cmd = tc
 			}
-			got := Autocomplete(cmd, test.args, 0)
+			got, err := Autocomplete(cmd, test.args, 0)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if !strings.HasPrefix(gotErr, test.wantErr) || (gotErr != "" && test.wantErr == "") {
+				t.Errorf("Autocomplete(%v, %v) returned error %q; want prefix %q", cmd, test.args, gotErr, test.wantErr)
+			}
 			if len(got) == 0 {
 				got = nil
 			}
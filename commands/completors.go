@@ -28,6 +28,14 @@ type Completion struct {
 	IgnoreFilter       bool
 	DontComplete       bool
 	CaseInsenstiveSort bool
+	// Descriptions maps suggestions to a description of them. Descriptions
+	// are only displayed by shells that support them (e.g. zsh).
+	Descriptions map[string]string
+	// Prefix is the part of the argument that was removed from each
+	// suggestion (e.g. the directory that FileFetcher suggestions are in).
+	// It is only used by shells that match suggestions against the entire
+	// argument (e.g. zsh).
+	Prefix string
 }
 
 func BoolCompletor() *Completor {
@@ -38,30 +46,38 @@ func BoolCompletor() *Completor {
 
 type boolFetcher struct{}
 
-func (*boolFetcher) Fetch(value *Value, args, flags map[string]*Value) *Completion {
+func (*boolFetcher) Fetch(value *Value, args, flags map[string]*Value) (*Completion, error) {
 	var keys []string
 	for k := range boolStringMap {
 		keys = append(keys, k)
 	}
 	return &Completion{
 		Suggestions: keys,
-	}
+	}, nil
 }
 
 type Fetcher interface {
 	// Fetch fetches all other options given the command arguments and flags.
-	Fetch(value *Value, args, flags map[string]*Value) *Completion
+	Fetch(value *Value, args, flags map[string]*Value) (*Completion, error)
 }
 
-func (c *Completor) Complete(rawValue string, value *Value, args, flags map[string]*Value) *Completion {
+func (c *Completor) Complete(rawValue string, value *Value, args, flags map[string]*Value) (*Completion, error) {
 	if c == nil || c.SuggestionFetcher == nil {
-		return nil
+		tracef("no fetcher defined")
+		return nil, nil
 	}
 
-	completion := c.SuggestionFetcher.Fetch(value, args, flags)
+	tracef("running fetcher %T", c.SuggestionFetcher)
+	completion, err := c.SuggestionFetcher.Fetch(value, args, flags)
+	if err != nil {
+		tracef("fetcher failed: %v", err)
+		return nil, fmt.Errorf("failed to fetch suggestions: %v", err)
+	}
 	if completion == nil {
-		return nil
+		tracef("fetcher returned no completion")
+		return nil, nil
 	}
+	tracef("fetcher returned suggestions %q", completion.Suggestions)
 	allOpts := completion.Suggestions
 
 	// Filter out prefixes.
@@ -78,7 +94,7 @@ func (c *Completor) Complete(rawValue string, value *Value, args, flags map[stri
 	if !c.Distinct || value.StringList() == nil {
 		// TODO: if we ever want to autocomplete non-string types, we should make Fetch
 		// return Value types (and add public methods to construct int, string, float values).
-		return completion
+		return completion, nil
 	}
 
 	existingValues := map[string]bool{}
@@ -98,19 +114,22 @@ func (c *Completor) Complete(rawValue string, value *Value, args, flags map[stri
 		}
 	}
 	completion.Suggestions = distinctOpts
-	return completion
+	return completion, nil
 }
 
 type NoopFetcher struct{}
 
-func (nf *NoopFetcher) Fetch(_ *Value, _, _ map[string]*Value) *Completion { return nil }
+func (nf *NoopFetcher) Fetch(_ *Value, _, _ map[string]*Value) (*Completion, error) { return nil, nil }
 
 type ListFetcher struct {
 	Options []string
+	// Descriptions maps options to a description of them (see
+	// Completion.Descriptions).
+	Descriptions map[string]string
 }
 
-func (lf *ListFetcher) Fetch(_ *Value, _, _ map[string]*Value) *Completion {
-	return &Completion{Suggestions: lf.Options}
+func (lf *ListFetcher) Fetch(_ *Value, _, _ map[string]*Value) (*Completion, error) {
+	return &Completion{Suggestions: lf.Options, Descriptions: lf.Descriptions}, nil
 }
 
 type FileFetcher struct {
@@ -124,7 +143,7 @@ type FileFetcher struct {
 	IgnoreDirectories bool
 }
 
-func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) *Completion {
+func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) (*Completion, error) {
 	var lastArg string
 	if value.IsType(StringType) {
 		lastArg = value.String()
@@ -136,12 +155,12 @@ func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) *Compl
 	laDir, laFile := filepath.Split(lastArg)
 	dir, err := filepathAbs(filepath.Join(ff.Directory, laDir))
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
 	}
 
 	files, err := ioutil.ReadDir(dir)
 	if err != nil {
-		return nil
+		return nil, fmt.Errorf("failed to read dir: %v", err)
 	}
 
 	onlyDir := true
@@ -168,7 +187,7 @@ func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) *Compl
 	}
 
 	if len(suggestions) == 0 {
-		return nil
+		return nil, nil
 	}
 
 	// Remove any non-distinct matches, if relevant.
@@ -187,7 +206,7 @@ func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) *Compl
 			}
 		}
 		if len(distinctSuggestions) == 0 {
-			return nil
+			return nil, nil
 		}
 		suggestions = distinctSuggestions
 	}
@@ -209,7 +228,7 @@ func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) *Compl
 			// without a space after it.
 			c.Suggestions = append(c.Suggestions, fmt.Sprintf("%s%s", c.Suggestions[0], suffixChar))
 		}
-		return c
+		return c, nil
 	}
 
 	autoFill, ok := getAutofillLetters(laFile, c.Suggestions)
@@ -219,7 +238,8 @@ func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) *Compl
 		// prefix so this would actually autocomplete to the prefix
 		// without the directory name
 		c.DontComplete = true
-		return c
+		c.Prefix = laDir
+		return c, nil
 	}
 
 	// Otherwise, we should complete all of the autofill letters
@@ -229,7 +249,7 @@ func (ff *FileFetcher) Fetch(value *Value, args, flags map[string]*Value) *Compl
 		autoFill,
 		autoFill + suffixChar,
 	}
-	return c
+	return c, nil
 }
 
 func getAutofillLetters(laFile string, suggestions []string) (string, bool) {
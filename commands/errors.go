@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// ExitCodeSuccess is the exit code for a successful command.
+	ExitCodeSuccess = 0
+	// ExitCodeExecutor is the exit code for an ExecutorError (and any other
+	// error that isn't a UsageError or ValidationError).
+	ExitCodeExecutor = 1
+	// ExitCodeUsage is the exit code for a UsageError.
+	ExitCodeUsage = 2
+	// ExitCodeValidation is the exit code for a ValidationError.
+	ExitCodeValidation = 3
+)
+
+// UsageError is returned when a command is invoked with the wrong arguments
+// (e.g. missing arguments or an unknown subcommand).
+type UsageError struct {
+	Err error
+}
+
+func (ue *UsageError) Error() string { return ue.Err.Error() }
+func (ue *UsageError) Unwrap() error { return ue.Err }
+
+// ValidationError is returned when the value provided for an argument or flag
+// is invalid.
+type ValidationError struct {
+	// ArgName is the name of the argument or flag that failed validation.
+	ArgName string
+	Err     error
+}
+
+func (ve *ValidationError) Error() string { return ve.Err.Error() }
+func (ve *ValidationError) Unwrap() error { return ve.Err }
+
+// ExecutorError is returned when a command's Executor fails.
+type ExecutorError struct {
+	Err error
+	// reported is true if the error was already written to stderr.
+	reported bool
+}
+
+func (ee *ExecutorError) Error() string { return ee.Err.Error() }
+func (ee *ExecutorError) Unwrap() error { return ee.Err }
+
+func usageErrorf(format string, a ...interface{}) error {
+	return &UsageError{Err: fmt.Errorf(format, a...)}
+}
+
+func validationError(argName string, err error) error {
+	return &ValidationError{ArgName: argName, Err: err}
+}
+
+// executorError wraps err in an ExecutorError unless it's already a typed
+// error.
+func executorError(err error) error {
+	var ue *UsageError
+	var ve *ValidationError
+	var ee *ExecutorError
+	if err == nil || errors.As(err, &ue) || errors.As(err, &ve) || errors.As(err, &ee) {
+		return err
+	}
+	return &ExecutorError{Err: err}
+}
+
+// withHint appends hint to the message of a UsageError or ValidationError.
+// Other errors are returned unchanged.
+func withHint(err error, hint string) error {
+	var ue *UsageError
+	if errors.As(err, &ue) {
+		return &UsageError{Err: fmt.Errorf("%v%s", ue.Err, hint)}
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return &ValidationError{ArgName: ve.ArgName, Err: fmt.Errorf("%v%s", ve.Err, hint)}
+	}
+	return err
+}
+
+// reported returns whether err was already written to stderr.
+func reported(err error) bool {
+	var ee *ExecutorError
+	return errors.As(err, &ee) && ee.reported
+}
+
+// ExitCode returns the exit code for the given error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	var ue *UsageError
+	if errors.As(err, &ue) {
+		return ExitCodeUsage
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return ExitCodeValidation
+	}
+	return ExitCodeExecutor
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(min(prev[j]+1, cur[j-1]+1), prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+// maxSuggestions is the maximum number of names suggested by didYouMean.
+const maxSuggestions = 3
+
+// didYouMean returns a hint with the names that are similar to (or start
+// with) s, or an empty string if there are none. Leading dashes aren't
+// considered when deciding whether a flag name is similar.
+func didYouMean(s string, names []string) string {
+	if s == "" {
+		return ""
+	}
+	distances := map[string]int{}
+	for _, n := range names {
+		d := editDistance(s, n)
+		if strings.HasPrefix(n, s) || (d <= 2 && 2*d < len([]rune(strings.TrimLeft(n, "-")))) {
+			distances[n] = d
+		}
+	}
+	if len(distances) == 0 {
+		return ""
+	}
+
+	similar := make([]string, 0, len(distances))
+	for n := range distances {
+		similar = append(similar, n)
+	}
+	sort.Slice(similar, func(i, j int) bool {
+		if distances[similar[i]] != distances[similar[j]] {
+			return distances[similar[i]] < distances[similar[j]]
+		}
+		return similar[i] < similar[j]
+	})
+	if len(similar) > maxSuggestions {
+		similar = similar[:maxSuggestions]
+	}
+
+	quoted := make([]string, 0, len(similar))
+	for _, n := range similar {
+		quoted = append(quoted, fmt.Sprintf("%q", n))
+	}
+	if len(quoted) == 1 {
+		return fmt.Sprintf(" (did you mean %s?)", quoted[0])
+	}
+	return fmt.Sprintf(" (did you mean %s or %s?)", strings.Join(quoted[:len(quoted)-1], ", "), quoted[len(quoted)-1])
+}
@@ -0,0 +1,264 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExecuteErrors(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		args         []string
+		ex           Executor
+		wantExitCode int
+		wantArgName  string
+		wantStderr   []string
+	}{
+		{
+			name:         "succeeds",
+			args:         []string{"basic", "un", "deux"},
+			wantExitCode: ExitCodeSuccess,
+		},
+		{
+			name:         "returns usage error for missing subcommand",
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{"more args required"},
+		},
+		{
+			name:         "returns usage error for unknown subcommand",
+			args:         []string{"huh"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{"unknown subcommand and no terminus command defined"},
+		},
+		{
+			name:         "suggests similar subcommand",
+			args:         []string{"basik"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{`unknown subcommand and no terminus command defined (did you mean "basic"?)`},
+		},
+		{
+			name:         "suggests subcommands with prefix",
+			args:         []string{"bas"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{`unknown subcommand and no terminus command defined (did you mean "basic" or "basically"?)`},
+		},
+		{
+			name:         "suggests similar subcommand when terminus command fails",
+			args:         []string{"advanced", "frist", "x", "y"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{`extra unknown args ([y]) (did you mean "first"?)`},
+		},
+		{
+			name: "doesn't suggest similar subcommand when executor fails",
+			args: []string{"advanced", "frist"},
+			ex: func(CommandOS, map[string]*Value, map[string]*Value, *OptionInfo) (*ExecutorResponse, error) {
+				return nil, fmt.Errorf("oops")
+			},
+			wantExitCode: ExitCodeExecutor,
+			wantStderr:   []string{"oops"},
+		},
+		{
+			name:         "suggests similar flag",
+			args:         []string{"basic", "un", "deux", "--americn"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{`extra unknown args ([--americn]) (did you mean "--american"?)`},
+		},
+		{
+			name:         "suggests similar flag for flag with value",
+			args:         []string{"basic", "un", "deux", "--stat=ca"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{`extra unknown args ([--stat=ca]) (did you mean "--state"?)`},
+		},
+		{
+			name:         "returns usage error for missing args",
+			args:         []string{"basic", "un"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{`not enough arguments provided for "variable 2"`},
+		},
+		{
+			name:         "returns usage error for extra args",
+			args:         []string{"basic", "un", "deux", "trois"},
+			wantExitCode: ExitCodeUsage,
+			wantStderr:   []string{"extra unknown args ([trois])"},
+		},
+		{
+			name:         "returns validation error for invalid arg",
+			args:         []string{"valueTypes", "int", "123.45"},
+			wantExitCode: ExitCodeValidation,
+			wantArgName:  "req",
+			wantStderr:   []string{`argument should be an integer: strconv.Atoi: parsing "123.45": invalid syntax`},
+		},
+		{
+			name:         "returns validation error for invalid flag",
+			args:         []string{"valueTypes", "int", "1", "-v", "123.45"},
+			wantExitCode: ExitCodeValidation,
+			wantArgName:  "vFlag",
+			wantStderr:   []string{`argument should be an integer: strconv.Atoi: parsing "123.45": invalid syntax`},
+		},
+		{
+			name:         "returns executor error for missing executor",
+			args:         []string{"advanced", "other"},
+			wantExitCode: ExitCodeExecutor,
+			wantStderr:   []string{"no executor defined for command"},
+		},
+		{
+			name: "returns executor error when executor fails",
+			args: []string{"basic", "un", "deux"},
+			ex: func(CommandOS, map[string]*Value, map[string]*Value, *OptionInfo) (*ExecutorResponse, error) {
+				return nil, fmt.Errorf("oops")
+			},
+			wantExitCode: ExitCodeExecutor,
+			wantStderr:   []string{"oops"},
+		},
+		{
+			name: "returns typed error from executor",
+			args: []string{"basic", "un", "deux"},
+			ex: func(CommandOS, map[string]*Value, map[string]*Value, *OptionInfo) (*ExecutorResponse, error) {
+				return nil, &ValidationError{ArgName: "val_1", Err: fmt.Errorf("un is not allowed")}
+			},
+			wantExitCode: ExitCodeValidation,
+			wantArgName:  "val_1",
+			wantStderr:   []string{"un is not allowed"},
+		},
+		{
+			name: "doesn't print error again for bool executor",
+			args: []string{"basic", "un", "deux"},
+			ex: FromBoolExecutor(func(cos CommandOS, _, _ map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+				cos.Stderr("already printed")
+				return nil, false
+			}),
+			wantExitCode: ExitCodeExecutor,
+			wantStderr:   []string{"already printed"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			ex := test.ex
+			if ex == nil {
+				ex = NoopExecutor
+			}
+			tcos := &TestCommandOS{}
+			_, err := Execute(tcos, branchCommand(ex, &Completor{}), test.args, nil)
+			if got := ExitCode(err); got != test.wantExitCode {
+				t.Errorf("ExitCode(Execute(%v)) returned %d; want %d (error: %v)", test.args, got, test.wantExitCode, err)
+			}
+
+			var gotArgName string
+			var ve *ValidationError
+			if errors.As(err, &ve) {
+				gotArgName = ve.ArgName
+			}
+			if gotArgName != test.wantArgName {
+				t.Errorf("Execute(%v) returned ValidationError for arg %q; want %q", test.args, gotArgName, test.wantArgName)
+			}
+
+			if diff := cmp.Diff(test.wantStderr, tcos.GetStderr()); diff != "" {
+				t.Errorf("Execute(%v) produced stderr diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{
+			name: "nil error",
+			want: ExitCodeSuccess,
+		},
+		{
+			name: "untyped error",
+			err:  fmt.Errorf("oops"),
+			want: ExitCodeExecutor,
+		},
+		{
+			name: "usage error",
+			err:  usageErrorf("oops"),
+			want: ExitCodeUsage,
+		},
+		{
+			name: "validation error",
+			err:  validationError("arg", fmt.Errorf("oops")),
+			want: ExitCodeValidation,
+		},
+		{
+			name: "executor error",
+			err:  &ExecutorError{Err: fmt.Errorf("oops")},
+			want: ExitCodeExecutor,
+		},
+		{
+			name: "usage error with hint",
+			err:  withHint(usageErrorf("oops"), " (did you mean?)"),
+			want: ExitCodeUsage,
+		},
+		{
+			name: "validation error with hint",
+			err:  withHint(validationError("arg", fmt.Errorf("oops")), " (did you mean?)"),
+			want: ExitCodeValidation,
+		},
+		{
+			name: "wrapped error",
+			err:  fmt.Errorf("context: %w", usageErrorf("oops")),
+			want: ExitCodeUsage,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExitCode(test.err); got != test.want {
+				t.Errorf("ExitCode(%v) returned %d; want %d", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		s     string
+		names []string
+		want  string
+	}{
+		{
+			name:  "no similar names",
+			s:     "xyz",
+			names: []string{"add", "delete"},
+		},
+		{
+			name:  "empty string",
+			names: []string{"add", "delete"},
+		},
+		{
+			name:  "typo",
+			s:     "delte",
+			names: []string{"add", "delete"},
+			want:  ` (did you mean "delete"?)`,
+		},
+		{
+			name:  "sorts by distance",
+			s:     "cat",
+			names: []string{"cart", "cast", "cat-food", "chat", "coat"},
+			want:  ` (did you mean "cart", "cast" or "chat"?)`,
+		},
+		{
+			name:  "doesn't suggest short flags for different letters",
+			s:     "-x",
+			names: []string{"-v", "--verbose"},
+		},
+		{
+			name:  "suggests long flags",
+			s:     "--verbos",
+			names: []string{"-v", "--verbose", "--version"},
+			want:  ` (did you mean "--verbose"?)`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, didYouMean(test.s, test.names)); diff != "" {
+				t.Errorf("didYouMean(%q, %v) returned diff (-want, +got):\n%s", test.s, test.names, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// quoteFish returns s quoted so fish interprets it as a single literal word.
+func quoteFish(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `\'`))
+}
+
+// FishCompletion returns a fish script that sets up autocompletion for cli.
+// It can be sourced from config.fish or saved in the completions directory.
+//
+// The generated function fetches suggestions by running
+//
+//	binary autocomplete-fish CLI CURSOR_IDX WORDS...
+//
+// where WORDS are the words of the command line (excluding the CLI name) up
+// to and including the word at the cursor. binary should pass everything after
+// the CLI name to RunFishAutocomplete.
+func FishCompletion(binary, cli string) string {
+	fn := functionName(cli, "completion")
+	return strings.Join([]string{
+		fmt.Sprintf("# Autocompletion for %s.", cli),
+		fmt.Sprintf("function %s", fn),
+		"    set -l tokens (commandline -opc)",
+		"    set -e tokens[1]",
+		fmt.Sprintf("    %s autocomplete-fish %s (math (count $tokens) + 1) $tokens (commandline -ct)", quoteFish(binary), quoteFish(cli)),
+		"end",
+		fmt.Sprintf("complete -c %s -f -a '(%s)'", quoteFish(cli), fn),
+		"",
+	}, "\n")
+}
+
+// partialCompletion returns the partial completion in a Completion that only
+// contains a suggestion and its suffixed duplicate (see FileFetcher).
+func partialCompletion(completion *Completion) (string, bool) {
+	s := completion.Suggestions
+	if len(s) != 2 || s[1] != s[0]+suffixChar {
+		return "", false
+	}
+	return s[0], true
+}
+
+// FishAutocomplete completes the given unparsed command for the script
+// generated by FishCompletion. Each line of the response is a suggestion,
+// optionally followed by a tab and its description.
+//
+// Fish filters suggestions against the entire word and inserts the shared
+// prefix of suggestions (without adding a space after directories) itself, so
+// the bash-specific parts of a Completion are translated:
+//   - the Completion's Prefix is prepended to every suggestion.
+//   - DontComplete is ignored.
+//   - partial completions (a suggestion with a suffixed duplicate) are
+//     replaced by the suggestions for the partial completion itself, unless
+//     the partial completion is a directory. Fish would otherwise add a space
+//     after the partial completion.
+func FishAutocomplete(c Command, unparsedArgs []string, cursorIdx int) ([]string, error) {
+	args, _ := completionArgs(unparsedArgs, cursorIdx)
+	completion, err := completeArgs(c, args)
+	if err != nil {
+		return nil, err
+	}
+	if partial, ok := partialCompletion(completion); ok && !strings.HasSuffix(partial, "/") {
+		args[len(args)-1] = partial
+		if completion, err = completeArgs(c, args); err != nil {
+			return nil, err
+		}
+	}
+	if partial, ok := partialCompletion(completion); ok {
+		completion.Suggestions = []string{partial}
+	}
+
+	var r []string
+	for _, s := range completion.Suggestions {
+		if d := completion.Descriptions[s]; d != "" {
+			r = append(r, fmt.Sprintf("%s%s\t%s", completion.Prefix, s, d))
+		} else {
+			r = append(r, completion.Prefix+s)
+		}
+	}
+	return r, nil
+}
+
+// RunFishAutocomplete handles a completion request from the script generated
+// by FishCompletion. args are the cursor index followed by the words of the
+// command line (excluding the CLI name).
+func RunFishAutocomplete(cos CommandOS, c Command, args []string) error {
+	return runAutocomplete(cos, c, args, FishAutocomplete)
+}
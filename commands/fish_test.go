@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFishCompletion(t *testing.T) {
+	want := strings.Join([]string{
+		"# Autocompletion for my-cli.",
+		"function _my_cli_completion",
+		"    set -l tokens (commandline -opc)",
+		"    set -e tokens[1]",
+		`    '/usr/bin/it\'s' autocomplete-fish 'my-cli' (math (count $tokens) + 1) $tokens (commandline -ct)`,
+		"end",
+		"complete -c 'my-cli' -f -a '(_my_cli_completion)'",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, FishCompletion("/usr/bin/it's", "my-cli")); diff != "" {
+		t.Errorf("FishCompletion() returned diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestFishAutocomplete(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		fetcher Fetcher
+		args    []string
+		want    []string
+	}{
+		{
+			name: "returns nothing for no suggestions",
+			fetcher: &ListFetcher{
+				Options: []string{"one", "two"},
+			},
+			args: []string{"three"},
+		},
+		{
+			name: "returns unescaped suggestions",
+			fetcher: &ListFetcher{
+				Options: []string{"with space", "without", "other"},
+			},
+			args: []string{"w"},
+			want: []string{"with space", "without"},
+		},
+		{
+			name: "returns descriptions",
+			fetcher: &completionFetcher{
+				completion: &Completion{
+					Suggestions: []string{"ab", "cd"},
+					Descriptions: map[string]string{
+						"ab": "first",
+					},
+				},
+			},
+			want: []string{"ab\tfirst", "cd"},
+		},
+		{
+			name:    "returns full file paths",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/dir1/"},
+			want: []string{
+				"testing/dir1/first.txt",
+				"testing/dir1/fourth.py",
+				"testing/dir1/second.py",
+				"testing/dir1/third.go",
+			},
+		},
+		{
+			name:    "returns single directory without duplicate",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/dir1"},
+			want:    []string{"testing/dir1/"},
+		},
+		{
+			name:    "returns suggestions for partial completion",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/d"},
+			want: []string{
+				"testing/dir1/",
+				"testing/dir2/",
+				"testing/dir3/",
+				"testing/dir4/",
+			},
+		},
+		{
+			name:    "returns suggestions for case-insensitive partial completion",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/moreCases/q"},
+			want: []string{
+				"testing/moreCases/QW_four.txt",
+				"testing/moreCases/qw_one.txt",
+				"testing/moreCases/qW_three.txt",
+				"testing/moreCases/qw_TRES.txt",
+				"testing/moreCases/Qw_two.txt",
+			},
+		},
+		{
+			name:    "returns single file",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/dir1/fi"},
+			want:    []string{"testing/dir1/first.txt"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &TerminusCommand{
+				Args: []Arg{
+					StringArg("test", true, &Completor{SuggestionFetcher: test.fetcher}),
+				},
+			}
+			got, err := FishAutocomplete(cmd, test.args, 0)
+			if err != nil {
+				t.Fatalf("FishAutocomplete(%v) returned error: %v", test.args, err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("FishAutocomplete(%v) returned diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestRunFishAutocomplete(t *testing.T) {
+	tcos := &TestCommandOS{}
+	if err := RunFishAutocomplete(tcos, branchCommand(NoopExecutor, &Completor{}), []string{"1", "b"}); err != nil {
+		t.Errorf("RunFishAutocomplete() returned error: %v", err)
+	}
+	want := []string{"basic", "basically", "beginner"}
+	if diff := cmp.Diff(want, tcos.GetStdout()); diff != "" {
+		t.Errorf("RunFishAutocomplete() produced stdout diff (-want, +got):\n%s", diff)
+	}
+}
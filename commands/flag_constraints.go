@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FlagConstraint is a relationship between the flags of a TerminusCommand
+// that is checked after the flags are parsed. Flags that are only set by
+// their default value (see Default) don't count as provided.
+type FlagConstraint interface {
+	// Check returns an error if the flag values violate the constraint.
+	Check(flags map[string]*Value) error
+	// Conflicts returns the flags that can't be used with the given flag
+	// values.
+	Conflicts(flags map[string]*Value) []string
+	// Usage returns a description of the constraint.
+	Usage() string
+}
+
+// flagNames returns the flag names formatted for usage and error messages.
+func flagNames(names []string) string {
+	formatted := make([]string, 0, len(names))
+	for _, n := range names {
+		formatted = append(formatted, fmt.Sprintf("--%s", n))
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// provided returns whether the named flag was provided (see Value.Provided).
+func provided(flags map[string]*Value, name string) bool {
+	return flags[name].Provided()
+}
+
+// used returns whether the named flag was provided and isn't a bool flag that
+// is set to false (e.g. with "--no-<name>").
+func used(flags map[string]*Value, name string) bool {
+	v := flags[name]
+	return v.Provided() && !(v.IsType(BoolType) && !v.Bool())
+}
+
+type requiredFlags struct {
+	names []string
+}
+
+// RequiredFlags requires all of the given flags to be provided.
+func RequiredFlags(names ...string) FlagConstraint {
+	return &requiredFlags{names}
+}
+
+func (rf *requiredFlags) Check(flags map[string]*Value) error {
+	for _, n := range rf.names {
+		if !provided(flags, n) {
+			return usageErrorf("missing required flag %q", n)
+		}
+	}
+	return nil
+}
+
+func (rf *requiredFlags) Conflicts(map[string]*Value) []string {
+	return nil
+}
+
+func (rf *requiredFlags) Usage() string {
+	if len(rf.names) == 1 {
+		return fmt.Sprintf("%s is required", flagNames(rf.names))
+	}
+	return fmt.Sprintf("%s are required", flagNames(rf.names))
+}
+
+type exclusiveFlags struct {
+	names []string
+}
+
+// ExclusiveFlags allows at most one of the given flags to be provided. Bool
+// flags that are set to false don't count. If one of the flags is provided on
+// the command line, the values of the others from their environment variables
+// or config keys (see EnvVar and ConfigKey) are ignored.
+func ExclusiveFlags(names ...string) FlagConstraint {
+	return &exclusiveFlags{names}
+}
+
+func (ef *exclusiveFlags) Check(flags map[string]*Value) error {
+	var usedNames []string
+	for _, n := range ef.names {
+		if used(flags, n) {
+			usedNames = append(usedNames, n)
+		}
+	}
+	if len(usedNames) > 1 {
+		return usageErrorf("flags %q and %q can't be used together", usedNames[0], usedNames[1])
+	}
+	return nil
+}
+
+func (ef *exclusiveFlags) Conflicts(flags map[string]*Value) []string {
+	for _, n := range ef.names {
+		if !used(flags, n) {
+			continue
+		}
+		var conflicts []string
+		for _, other := range ef.names {
+			if other != n {
+				conflicts = append(conflicts, other)
+			}
+		}
+		return conflicts
+	}
+	return nil
+}
+
+func (ef *exclusiveFlags) Usage() string {
+	return fmt.Sprintf("%s are mutually exclusive", flagNames(ef.names))
+}
+
+type oneOfFlags struct {
+	names []string
+}
+
+// OneOfFlags requires at least one of the given flags to be provided.
+func OneOfFlags(names ...string) FlagConstraint {
+	return &oneOfFlags{names}
+}
+
+func (oof *oneOfFlags) Check(flags map[string]*Value) error {
+	for _, n := range oof.names {
+		if provided(flags, n) {
+			return nil
+		}
+	}
+	return usageErrorf("at least one of the flags [%s] is required", flagNames(oof.names))
+}
+
+func (oof *oneOfFlags) Conflicts(map[string]*Value) []string {
+	return nil
+}
+
+func (oof *oneOfFlags) Usage() string {
+	return fmt.Sprintf("at least one of %s is required", flagNames(oof.names))
+}
+
+type flagRequires struct {
+	name     string
+	requires []string
+}
+
+// FlagRequires requires the required flags to be provided whenever the named
+// flag is provided. A bool flag that is set to false doesn't require anything.
+func FlagRequires(name string, required ...string) FlagConstraint {
+	return &flagRequires{name, required}
+}
+
+func (fr *flagRequires) Check(flags map[string]*Value) error {
+	if !used(flags, fr.name) {
+		return nil
+	}
+	for _, r := range fr.requires {
+		if !provided(flags, r) {
+			return usageErrorf("flag %q requires flag %q", fr.name, r)
+		}
+	}
+	return nil
+}
+
+func (fr *flagRequires) Conflicts(map[string]*Value) []string {
+	return nil
+}
+
+func (fr *flagRequires) Usage() string {
+	return fmt.Sprintf("--%s requires %s", fr.name, flagNames(fr.requires))
+}
+
+// checkFlagConstraints returns the first constraint violation (if any).
+func checkFlagConstraints(constraints []FlagConstraint, flags map[string]*Value) error {
+	for _, c := range constraints {
+		if err := c.Check(flags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// conflictingFlags returns the flags that can't be used with the given flag
+// values.
+func conflictingFlags(constraints []FlagConstraint, flags map[string]*Value) map[string]bool {
+	conflicts := map[string]bool{}
+	for _, c := range constraints {
+		for _, n := range c.Conflicts(flags) {
+			conflicts[n] = true
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,276 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func constrainedCommand(constraints ...FlagConstraint) *TerminusCommand {
+	return &TerminusCommand{
+		Executor: NoopExecutor,
+		Flags: []Flag{
+			StringFlag("name", 'n', nil),
+			BoolFlag("json", 'j', EnvVar("JSON")),
+			BoolFlag("yaml", 'y', ConfigKey("yaml")),
+			BoolFlag("text", 't'),
+			IntFlag("port", 'p', nil, Default(IntValue(80))),
+			StringFlag("host", 0, nil),
+		},
+		FlagConstraints: constraints,
+	}
+}
+
+func TestFlagConstraints(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		constraints []FlagConstraint
+		args        []string
+		env         map[string]string
+		config      map[string][]string
+		wantErr     string
+		wantFlags   map[string]*Value
+	}{
+		{
+			name:        "required flag provided",
+			constraints: []FlagConstraint{RequiredFlags("name")},
+			args:        []string{"-n", "abc"},
+		},
+		{
+			name:        "required flag missing",
+			constraints: []FlagConstraint{RequiredFlags("json", "name")},
+			args:        []string{"-j"},
+			wantErr:     `missing required flag "name"`,
+		},
+		{
+			name:        "default value doesn't satisfy required flag",
+			constraints: []FlagConstraint{RequiredFlags("port")},
+			wantErr:     `missing required flag "port"`,
+		},
+		{
+			name:        "single exclusive flag",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml", "text")},
+			args:        []string{"--yaml"},
+		},
+		{
+			name:        "multiple exclusive flags",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml", "text")},
+			args:        []string{"-t", "-j"},
+			wantErr:     `flags "json" and "text" can't be used together`,
+		},
+		{
+			name:        "exclusive flag set to false isn't used",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml")},
+			args:        []string{"--no-json", "-y"},
+			wantFlags: map[string]*Value{
+				"json": BoolValue(false),
+				"yaml": BoolValue(true),
+				"port": IntValue(80),
+			},
+		},
+		{
+			name:        "exclusive env flag set to false isn't used",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml")},
+			env:         map[string]string{"JSON": "false"},
+			config:      map[string][]string{"yaml": {"true"}},
+			wantFlags: map[string]*Value{
+				"json": BoolValue(false),
+				"yaml": BoolValue(true),
+				"port": IntValue(80),
+			},
+		},
+		{
+			name:        "required flag set to false is provided",
+			constraints: []FlagConstraint{RequiredFlags("json")},
+			args:        []string{"--no-json"},
+		},
+		{
+			name:        "command line flag overrides exclusive env flag",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml")},
+			args:        []string{"-y"},
+			env:         map[string]string{"JSON": "true"},
+			wantFlags: map[string]*Value{
+				"yaml": BoolValue(true),
+				"port": IntValue(80),
+			},
+		},
+		{
+			name:        "command line flag overrides exclusive config flag",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml")},
+			args:        []string{"-j"},
+			config:      map[string][]string{"yaml": {"true"}},
+			wantFlags: map[string]*Value{
+				"json": BoolValue(true),
+				"port": IntValue(80),
+			},
+		},
+		{
+			name:        "exclusive env and config flags",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml")},
+			env:         map[string]string{"JSON": "true"},
+			config:      map[string][]string{"yaml": {"true"}},
+			wantErr:     `flags "json" and "yaml" can't be used together`,
+		},
+		{
+			name:        "env flag satisfies required flag",
+			constraints: []FlagConstraint{RequiredFlags("json"), ExclusiveFlags("name", "host")},
+			args:        []string{"-n", "abc"},
+			env:         map[string]string{"JSON": "true"},
+			wantFlags: map[string]*Value{
+				"name": StringValue("abc"),
+				"json": BoolValue(true),
+				"port": IntValue(80),
+			},
+		},
+		{
+			name:        "one of flags provided",
+			constraints: []FlagConstraint{OneOfFlags("name", "host")},
+			args:        []string{"--host", "localhost"},
+		},
+		{
+			name:        "none of flags provided",
+			constraints: []FlagConstraint{OneOfFlags("name", "host")},
+			wantErr:     "at least one of the flags [--name, --host] is required",
+		},
+		{
+			name:        "flag requires other flag",
+			constraints: []FlagConstraint{FlagRequires("port", "host")},
+			args:        []string{"-p", "8080"},
+			wantErr:     `flag "port" requires flag "host"`,
+		},
+		{
+			name:        "flag requirement satisfied",
+			constraints: []FlagConstraint{FlagRequires("port", "host")},
+			args:        []string{"-p", "8080", "--host", "localhost"},
+		},
+		{
+			name:        "requirement doesn't apply to flag set to false",
+			constraints: []FlagConstraint{FlagRequires("json", "host")},
+			args:        []string{"--no-json"},
+		},
+		{
+			name:        "requirement doesn't apply to default value",
+			constraints: []FlagConstraint{FlagRequires("port", "host")},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldGetenv := getenv
+			getenv = func(key string) string { return test.env[key] }
+			defer func() { getenv = oldGetenv }()
+
+			var gotFlags map[string]*Value
+			cmd := constrainedCommand(test.constraints...)
+			cmd.Executor = func(_ CommandOS, _, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
+				gotFlags = flags
+				return nil, nil
+			}
+			_, err := Execute(&TestCommandOS{}, cmd, test.args, &OptionInfo{Config: test.config})
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+				if got := ExitCode(err); got != ExitCodeUsage {
+					t.Errorf("ExitCode(%v) returned %d; want %d", err, got, ExitCodeUsage)
+				}
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr); diff != "" {
+				t.Errorf("Execute(%v) returned error diff (-want, +got):\n%s", test.args, diff)
+			}
+			if test.wantFlags != nil {
+				if diff := cmp.Diff(test.wantFlags, gotFlags); diff != "" {
+					t.Errorf("Execute(%v) produced flags diff (-want, +got):\n%s", test.args, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestFlagConstraintsComplete(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		constraints []FlagConstraint
+		args        []string
+		want        []string
+	}{
+		{
+			name:        "suggests all flags without conflicts",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml", "text")},
+			args:        []string{"--"},
+			want:        []string{"--host", "--json", "--name", "--port", "--text", "--yaml"},
+		},
+		{
+			name:        "doesn't suggest conflicting flags",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml", "text")},
+			args:        []string{"--yaml", "--"},
+			want:        []string{"--host", "--name", "--port", "--yaml"},
+		},
+		{
+			name:        "suggests flags that conflict with flag set to false",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml", "text")},
+			args:        []string{"--no-json", "--"},
+			want:        []string{"--host", "--json", "--name", "--port", "--text", "--yaml"},
+		},
+		{
+			name:        "doesn't suggest conflicting short flags in cluster",
+			constraints: []FlagConstraint{ExclusiveFlags("json", "yaml", "text")},
+			args:        []string{"-j"},
+			want:        []string{"-j"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Autocomplete(constrainedCommand(test.constraints...), test.args, len(test.args))
+			if err != nil {
+				t.Fatalf("Autocomplete(%v) returned error: %v", test.args, err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Autocomplete(%v) returned diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestFlagConstraintsUsage(t *testing.T) {
+	cmd := constrainedCommand(
+		RequiredFlags("name"),
+		ExclusiveFlags("json", "yaml"),
+		OneOfFlags("port", "host"),
+		FlagRequires("port", "host", "name"),
+	)
+	oldGetenv := getenv
+	getenv = func(string) string { return "" }
+	defer func() { getenv = oldGetenv }()
+
+	wantUsage := []string{
+		"--name|-n NAME [OPTIONS]",
+		"  Options: [--json|-j] [--yaml|-y] [--text|-t] [--port|-p PORT] [--host HOST]",
+		"  Constraints: --name is required",
+		"               --json, --yaml are mutually exclusive",
+		"               at least one of --port, --host is required",
+		"               --port requires --host, --name",
+	}
+	if diff := cmp.Diff(wantUsage, cmd.Usage()); diff != "" {
+		t.Errorf("Usage() returned diff (-want, +got):\n%s", diff)
+	}
+
+	tcos := &TestCommandOS{}
+	PrintHelp(tcos, "cli", cmd, nil)
+	wantHelp := []string{
+		"Usage: cli --name|-n NAME [OPTIONS]",
+		"",
+		"Options:",
+		"  --name, -n  String",
+		"  --json, -j  (env: JSON)",
+		"  --yaml, -y  (config: yaml)",
+		"  --text, -t",
+		"  --port, -p  Int (default: 80)",
+		"  --host      String",
+		"",
+		"Constraints:",
+		"  --name is required",
+		"  --json, --yaml are mutually exclusive",
+		"  at least one of --port, --host is required",
+		"  --port requires --host, --name",
+	}
+	if diff := cmp.Diff(wantHelp, tcos.GetStdout()); diff != "" {
+		t.Errorf("PrintHelp() produced stdout diff (-want, +got):\n%s", diff)
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func StringFlag(name string, shortName rune, completor *Completor, opts ...ArgOpt) Flag {
-	return &singleArgProcessor{
+	return newSingleArgProcessor(&singleArgProcessor{
 		name:      name,
 		completor: completor,
 		opts:      opts,
@@ -16,11 +16,11 @@ func StringFlag(name string, shortName rune, completor *Completor, opts ...ArgOp
 		transform: func(s string) (*Value, error) {
 			return StringValue(s), nil
 		},
-	}
+	})
 }
 
 func IntFlag(name string, shortName rune, completor *Completor, opts ...ArgOpt) Flag {
-	return &singleArgProcessor{
+	return newSingleArgProcessor(&singleArgProcessor{
 		name:      name,
 		completor: completor,
 		opts:      opts,
@@ -34,14 +34,14 @@ func IntFlag(name string, shortName rune, completor *Completor, opts ...ArgOpt)
 			}
 			return IntValue(i), err
 		},
-	}
+	})
 }
 func FloatFlag(name string, shortName rune, completor *Completor, opts ...ArgOpt) Flag {
-	return &singleArgProcessor{
+	return newSingleArgProcessor(&singleArgProcessor{
 		name:      name,
 		completor: completor,
 		opts:      opts,
-		vt:        IntType,
+		vt:        FloatType,
 		shortName: shortName,
 		flag:      true,
 		transform: func(s string) (*Value, error) {
@@ -51,17 +51,30 @@ func FloatFlag(name string, shortName rune, completor *Completor, opts ...ArgOpt
 			}
 			return FloatValue(f), err
 		},
-	}
+	})
 }
 func BoolFlag(name string, shortName rune, opts ...ArgOpt) Flag {
+	checkDefault(name, BoolType, false, opts)
 	return &boolFlagProcessor{
 		name:      name,
 		shortName: shortName,
+		opts:      opts,
+	}
+}
+
+// CountFlag returns a flag whose value is the number of times it's provided
+// (e.g. "-vvv" sets it to 3).
+func CountFlag(name string, shortName rune, opts ...ArgOpt) Flag {
+	checkDefault(name, IntType, false, opts)
+	return &countFlagProcessor{
+		name:      name,
+		shortName: shortName,
+		opts:      opts,
 	}
 }
 
 func StringListFlag(name string, shortName rune, minN, optionalN int, completor *Completor, opts ...ArgOpt) Flag {
-	return &listArgProcessor{
+	return newListArgProcessor(&listArgProcessor{
 		name:      name,
 		minN:      minN,
 		optionalN: optionalN,
@@ -71,11 +84,11 @@ func StringListFlag(name string, shortName rune, minN, optionalN int, completor
 		flag:      true,
 		shortName: shortName,
 		transform: func(s []string) (*Value, error) { return StringListValue(s...), nil },
-	}
+	})
 }
 
 func IntListFlag(name string, shortName rune, minN, optionalN int, completor *Completor, opts ...ArgOpt) Flag {
-	return &listArgProcessor{
+	return newListArgProcessor(&listArgProcessor{
 		name:      name,
 		minN:      minN,
 		optionalN: optionalN,
@@ -85,11 +98,11 @@ func IntListFlag(name string, shortName rune, minN, optionalN int, completor *Co
 		flag:      true,
 		shortName: shortName,
 		transform: intListTransform,
-	}
+	})
 }
 
 func FloatListFlag(name string, shortName rune, minN, optionalN int, completor *Completor, opts ...ArgOpt) Flag {
-	return &listArgProcessor{
+	return newListArgProcessor(&listArgProcessor{
 		name:      name,
 		minN:      minN,
 		optionalN: optionalN,
@@ -99,5 +112,5 @@ func FloatListFlag(name string, shortName rune, minN, optionalN int, completor *
 		flag:      true,
 		shortName: shortName,
 		transform: floatListTransform,
-	}
+	})
 }
@@ -0,0 +1,335 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	helpFlag      = "--help"
+	shortHelpFlag = "-h"
+)
+
+// CommandUsage is a structured description of how a Command is used.
+type CommandUsage struct {
+	// Description is a description of the command (if any).
+	Description string
+	// Subcommands contains the usage of each subcommand, sorted by name.
+	Subcommands []*SubcommandUsage
+	// Args contains the usage of each positional argument, in order.
+	Args []*ArgUsage
+	// Flags contains the usage of each flag, in order.
+	Flags []*ArgUsage
+	// Constraints contains the usage of each FlagConstraint, in order.
+	Constraints []string
+}
+
+// SubcommandUsage is the usage of a named subcommand.
+type SubcommandUsage struct {
+	Name string
+	// Aliases are the alternate names of the subcommand.
+	Aliases []string
+	// Deprecated is the subcommand's deprecation warning (if any).
+	Deprecated string
+	Usage      *CommandUsage
+}
+
+// ArgUsage is a structured description of an Arg or Flag.
+type ArgUsage struct {
+	Name      string
+	ShortName rune
+	Flag      bool
+	// Type is the type of value produced by the argument.
+	Type ValueType
+	// MinN is the number of values that must be provided.
+	MinN int
+	// OptionalN is the number of additional values that may be provided
+	// (or UnboundedList if there is no limit).
+	OptionalN int
+	// Default is the value used when the argument isn't provided (if any).
+	Default *Value
+	// EnvVar is the environment variable that supplies the flag's value (if
+	// any).
+	EnvVar string
+	// ConfigKey is the config file key that supplies the flag's value (if
+	// any).
+	ConfigKey string
+	// Required is true for flags that must be provided (see RequiredFlags).
+	Required bool
+	// Repeatable is true for flags that can be provided multiple times (see
+	// Repeatable and CountFlag).
+	Repeatable bool
+	// Validators contains the descriptions of the argument's validating
+	// options (e.g. "must be positive" for IntPositive).
+	Validators []string
+	// Description is a description of the argument (see Description).
+	Description string
+}
+
+// placeholder returns the name used for the argument's values in usage text.
+func (au *ArgUsage) placeholder() string {
+	return strings.ReplaceAll(strings.ToUpper(au.Name), " ", "_")
+}
+
+// flagName returns the long and (if present) short names of a flag.
+func (au *ArgUsage) flagName(sep string) string {
+	if au.ShortName == 0 {
+		return fmt.Sprintf("--%s", au.Name)
+	}
+	return fmt.Sprintf("--%s%s-%c", au.Name, sep, au.ShortName)
+}
+
+// valueTokens returns the synopsis tokens for the argument's values.
+func (au *ArgUsage) valueTokens() []string {
+	n := au.placeholder()
+	tokens := make([]string, 0, au.MinN+1)
+	for i := 0; i < au.MinN; i++ {
+		tokens = append(tokens, n)
+	}
+
+	if au.OptionalN == UnboundedList {
+		tokens = append(tokens, fmt.Sprintf("[%s ...]", n))
+	} else if au.OptionalN > 0 {
+		opt := make([]string, 0, au.OptionalN)
+		for i := 0; i < au.OptionalN; i++ {
+			opt = append(opt, n)
+		}
+		tokens = append(tokens, fmt.Sprintf("[%s]", strings.Join(opt, " ")))
+	}
+	return tokens
+}
+
+// synopsis returns the argument as it should be displayed in a synopsis.
+func (au *ArgUsage) synopsis() string {
+	if !au.Flag {
+		return strings.Join(au.valueTokens(), " ")
+	}
+	flag := strings.Join(append([]string{au.flagName("|")}, au.valueTokens()...), " ")
+	if au.Required {
+		return flag
+	}
+	return fmt.Sprintf("[%s]", flag)
+}
+
+// description returns a short description of the argument's type, count and
+// the sources of its value.
+func (au *ArgUsage) description() string {
+	var parts []string
+	if d := au.typeDescription(); d != "" {
+		parts = append(parts, d)
+	}
+	if au.Repeatable {
+		parts = append(parts, "(repeatable)")
+	}
+	if au.EnvVar != "" {
+		parts = append(parts, fmt.Sprintf("(env: %s)", au.EnvVar))
+	}
+	if au.ConfigKey != "" {
+		parts = append(parts, fmt.Sprintf("(config: %s)", au.ConfigKey))
+	}
+	if au.Default != nil {
+		parts = append(parts, fmt.Sprintf("(default: %s)", au.Default.Str()))
+	}
+	return strings.Join(parts, " ")
+}
+
+// typeDescription returns a short description of the argument's type and
+// count.
+func (au *ArgUsage) typeDescription() string {
+	if au.Type == BoolType && au.Flag {
+		return ""
+	}
+	t := typeToString[au.Type]
+	if au.Flag && au.MinN == 0 && au.OptionalN == 0 {
+		// Flags without values (e.g. CountFlag).
+		return t
+	}
+	switch au.Type {
+	case StringListType, IntListType, FloatListType:
+	default:
+		if au.MinN == 0 {
+			return fmt.Sprintf("%s (optional)", t)
+		}
+		return t
+	}
+
+	switch {
+	case au.OptionalN == UnboundedList:
+		return fmt.Sprintf("%s (%d+)", t, au.MinN)
+	case au.OptionalN == 0:
+		return fmt.Sprintf("%s (%d)", t, au.MinN)
+	default:
+		return fmt.Sprintf("%s (%d-%d)", t, au.MinN, au.MinN+au.OptionalN)
+	}
+}
+
+// helpSection formats the rows of a help section with aligned columns.
+// Trailing empty columns are omitted.
+func helpSection(title string, rows [][]string) []string {
+	if len(rows) == 0 {
+		return nil
+	}
+	var widths []int
+	for _, r := range rows {
+		for i, c := range r {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if len(c) > widths[i] {
+				widths[i] = len(c)
+			}
+		}
+	}
+
+	lines := []string{"", fmt.Sprintf("%s:", title)}
+	for _, r := range rows {
+		cols := make([]string, 0, len(r))
+		for i, c := range r {
+			if i == len(r)-1 {
+				cols = append(cols, c)
+			} else {
+				cols = append(cols, fmt.Sprintf("%-*s", widths[i], c))
+			}
+		}
+		lines = append(lines, strings.TrimRight(fmt.Sprintf("  %s", strings.Join(cols, "  ")), " "))
+	}
+	return lines
+}
+
+// HelpText returns the help page for the provided usage. path contains the
+// words used to reach the command (e.g. the CLI name and subcommands).
+func HelpText(path []string, cu *CommandUsage) []string {
+	var lines []string
+	width, p := usageWidth(), strings.Join(path, " ")
+	indent := len("Usage: ") + len(p)
+	if p != "" {
+		indent++
+	}
+	for i, tokens := range cu.synopses() {
+		prefix := strings.TrimSpace(fmt.Sprintf("Usage: %s", p))
+		if i > 0 {
+			prefix = fmt.Sprintf("%*s%s", len("Usage: "), "", p)
+		}
+		lines = append(lines, wrapTokens(prefix, tokens, indent, width)...)
+	}
+	if cu.Description != "" {
+		lines = append(lines, "", cu.Description)
+	}
+
+	subcommands := make([][]string, 0, len(cu.Subcommands))
+	for _, sc := range cu.Subcommands {
+		var desc []string
+		if sc.Usage.Description != "" {
+			desc = append(desc, sc.Usage.Description)
+		}
+		if sc.Deprecated != "" {
+			desc = append(desc, fmt.Sprintf("(deprecated: %s)", sc.Deprecated))
+		}
+		subcommands = append(subcommands, []string{strings.Join(append([]string{sc.Name}, sc.Aliases...), ", "), strings.Join(desc, " ")})
+	}
+	lines = append(lines, helpSection("Subcommands", subcommands)...)
+
+	args := make([][]string, 0, len(cu.Args))
+	for _, a := range cu.Args {
+		args = append(args, []string{a.placeholder(), a.description(), a.Description})
+	}
+	lines = append(lines, helpSection("Arguments", args)...)
+
+	flags := make([][]string, 0, len(cu.Flags))
+	for _, f := range cu.Flags {
+		flags = append(flags, []string{f.flagName(", "), f.description(), f.Description})
+	}
+	lines = append(lines, helpSection("Options", flags)...)
+
+	constraints := make([][]string, 0, len(cu.Constraints))
+	for _, c := range cu.Constraints {
+		constraints = append(constraints, []string{c})
+	}
+	return append(lines, helpSection("Constraints", constraints)...)
+}
+
+// helpCommand returns the deepest command matched by args and the
+// subcommand names used to reach it.
+func helpCommand(c Command, args []string) (Command, []string) {
+	var path []string
+	for _, arg := range args {
+		cb, ok := c.(*CommandBranch)
+		if !ok {
+			break
+		}
+		name, sc, ok := cb.subcommand(arg)
+		if !ok {
+			break
+		}
+		c = sc
+		path = append(path, name)
+	}
+	return c, path
+}
+
+// withoutHelpFlags returns the args before the first flag terminator with all
+// help flags removed.
+func withoutHelpFlags(args []string) []string {
+	args, _, _ = splitAtTerminator(args)
+	filtered := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != helpFlag && arg != shortHelpFlag {
+			filtered = append(filtered, arg)
+		}
+	}
+	return filtered
+}
+
+// helpRequested returns whether args contain a help flag before the first
+// flag terminator. A help flag is ignored if the matched command defines a
+// flag with the same name or if it's the value of a flag (e.g.
+// "--message -h").
+func helpRequested(c Command, args []string) bool {
+	args, _, _ = splitAtTerminator(args)
+	hc, _ := helpCommand(c, withoutHelpFlags(args))
+	cu := hc.StructuredUsage()
+	long, short := true, true
+	for _, f := range cu.Flags {
+		if f.Name == strings.TrimPrefix(helpFlag, "--") {
+			long = false
+		}
+		if f.ShortName == rune(shortHelpFlag[1]) {
+			short = false
+		}
+	}
+
+	tc, _ := hc.(*TerminusCommand)
+	if cb, ok := hc.(*CommandBranch); ok {
+		tc = cb.TerminusCommand
+	}
+	var flagMap map[string]Flag
+	if tc != nil {
+		flagMap = tc.flagMap()
+	}
+
+	for idx := 0; idx < len(args); idx++ {
+		if expanded, ok := expandShortFlagCluster(args, idx, flagMap); ok {
+			args = expanded
+		}
+		arg := args[idx]
+		if (long && arg == helpFlag) || (short && arg == shortHelpFlag) {
+			return true
+		}
+		if flag, ok := flagMap[arg]; ok {
+			idx += flag.ProcessCompleteArgs(args[idx+1:], map[string]*Value{}, map[string]*Value{})
+		}
+	}
+	return false
+}
+
+// PrintHelp writes the help page of the command matched by args to stdout.
+// name is the name of the CLI and is omitted from the synopsis if empty.
+func PrintHelp(cos CommandOS, name string, c Command, args []string) {
+	hc, path := helpCommand(c, withoutHelpFlags(args))
+	if name != "" {
+		path = append([]string{name}, path...)
+	}
+	for _, line := range HelpText(path, hc.StructuredUsage()) {
+		cos.Stdout("%s", line)
+	}
+}
@@ -0,0 +1,350 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHelp(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		cmd        Command
+		args       []string
+		wantStdout []string
+		wantOK     bool
+	}{
+		{
+			name:   "prints help for root branch",
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: (advanced|basic|basically|beginner|cluster|defaults|dquo|fallbacks|",
+				"       ignore|info|inline|intermediate|matching|mw|prefixes|repeatable|",
+				"       sometimes|squo|switches|terminator|valueTypes|wave) ...",
+				"",
+				"Subcommands:",
+				"  advanced",
+				"  basic",
+				"  basically",
+				"  beginner",
+				"  cluster",
+				"  defaults",
+				"  dquo",
+				"  fallbacks",
+				"  ignore",
+				"  info",
+				"  inline",
+				"  intermediate",
+				"  matching",
+				"  mw",
+				"  prefixes",
+				"  repeatable",
+				"  sometimes",
+				"  squo",
+				"  switches",
+				"  terminator",
+				"  valueTypes",
+				"  wave",
+			},
+		},
+		{
+			name:   "prints help for terminus command",
+			args:   []string{"basic", "--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: basic VAL_1 VARIABLE_2 [OPTIONS]",
+				"",
+				"Arguments:",
+				"  VAL_1       StringList (1)",
+				"  VARIABLE_2  StringList (1)",
+				"",
+				"Options:",
+				"  --american, -a",
+				"  --another       StringList (1)",
+				"  --state, -s     StringList (1)",
+			},
+		},
+		{
+			name:   "short help flag works anywhere in args",
+			args:   []string{"valueTypes", "-h", "int", "not-an-int"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: valueTypes int REQ [OPT] [OPTIONS]",
+				"",
+				"Arguments:",
+				"  REQ  Int",
+				"  OPT  Int (optional)",
+				"",
+				"Options:",
+				"  --vFlag, -v  Int",
+			},
+		},
+		{
+			name:   "prints help for branch with terminus command",
+			args:   []string{"advanced", "--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: advanced (first|foremost|liszt|other) ...",
+				"       advanced [CB-COMMAND CB-COMMAND]",
+				"",
+				"Subcommands:",
+				"  first",
+				"  foremost",
+				"  liszt",
+				"  other",
+				"",
+				"Arguments:",
+				"  CB-COMMAND  StringList (0-2)",
+			},
+		},
+		{
+			name:   "prints list cardinality",
+			args:   []string{"advanced", "liszt", "-h"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: advanced liszt LIST-ARG [LIST-ARG ...] [OPTIONS]",
+				"",
+				"Arguments:",
+				"  LIST-ARG  StringList (1+)",
+				"",
+				"Options:",
+				"  --inside, -i  StringList (2)",
+			},
+		},
+		{
+			name: "short help flag is ignored if command defines it",
+			cmd: &TerminusCommand{
+				Executor: NoopExecutor,
+				Flags: []Flag{
+					StringFlag("host", 'h', nil),
+				},
+			},
+			args:   []string{"-h", "localhost"},
+			wantOK: true,
+		},
+		{
+			name: "long help flag works if command defines short help flag",
+			cmd: &TerminusCommand{
+				Executor: NoopExecutor,
+				Flags: []Flag{
+					StringFlag("host", 'h', nil),
+				},
+			},
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: [OPTIONS]",
+				"",
+				"Options:",
+				"  --host, -h  String",
+			},
+		},
+		{
+			name: "prints default values",
+			cmd: &TerminusCommand{
+				Args: []Arg{
+					StringArg("name", false, nil, Default(StringValue("world"))),
+				},
+				Flags: []Flag{
+					IntFlag("times", 't', nil, Default(IntValue(3))),
+					BoolFlag("loud", 'l', Default(BoolValue(true))),
+					StringListFlag("tags", 0, 0, UnboundedList, nil, Default(StringListValue("a", "b"))),
+				},
+			},
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: [NAME] [OPTIONS]",
+				"",
+				"Arguments:",
+				"  NAME  String (optional) (default: world)",
+				"",
+				"Options:",
+				"  --times, -t  Int (default: 3)",
+				"  --loud, -l   (default: true)",
+				"  --tags       StringList (0+) (default: a, b)",
+			},
+		},
+		{
+			name: "prints value sources",
+			cmd: &TerminusCommand{
+				Flags: []Flag{
+					IntFlag("times", 't', nil, EnvVar("TIMES"), ConfigKey("times"), Default(IntValue(3))),
+					BoolFlag("loud", 'l', EnvVar("LOUD")),
+				},
+			},
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: [OPTIONS]",
+				"",
+				"Options:",
+				"  --times, -t  Int (env: TIMES) (config: times) (default: 3)",
+				"  --loud, -l   (env: LOUD)",
+			},
+		},
+		{
+			name: "prints repeatable flags",
+			cmd: &TerminusCommand{
+				Flags: []Flag{
+					StringListFlag("tag", 't', 1, 0, nil, Repeatable()),
+					CountFlag("verbose", 'v'),
+				},
+			},
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: [OPTIONS]",
+				"",
+				"Options:",
+				"  --tag, -t      StringList (1) (repeatable)",
+				"  --verbose, -v  Int (repeatable)",
+			},
+		},
+		{
+			name: "prints descriptions",
+			cmd: &CommandBranch{
+				Description: "Greets people.",
+				Subcommands: map[string]Command{
+					"hello": &TerminusCommand{
+						Description: "Says hello.",
+					},
+					"wave": &TerminusCommand{},
+				},
+				SubcommandInfo: map[string]*SubcommandInfo{
+					"wave": {Deprecated: `use "hello" instead`},
+				},
+				TerminusCommand: &TerminusCommand{
+					Args: []Arg{
+						StringArg("name", true, nil, Description("Who to greet.")),
+					},
+					Flags: []Flag{
+						BoolFlag("loud", 'l', Description("Greet loudly.")),
+						IntFlag("times", 't', nil),
+					},
+				},
+			},
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: (hello|wave) ...",
+				"       NAME [OPTIONS]",
+				"",
+				"Greets people.",
+				"",
+				"Subcommands:",
+				"  hello  Says hello.",
+				`  wave   (deprecated: use "hello" instead)`,
+				"",
+				"Arguments:",
+				"  NAME  String  Who to greet.",
+				"",
+				"Options:",
+				"  --loud, -l        Greet loudly.",
+				"  --times, -t  Int",
+			},
+		},
+		{
+			name: "prints value types",
+			cmd: &TerminusCommand{
+				Args: []Arg{
+					BoolArg("enabled", true),
+				},
+				Flags: []Flag{
+					FloatFlag("ratio", 'r', nil, Default(FloatValue(0.5))),
+				},
+			},
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: ENABLED [OPTIONS]",
+				"",
+				"Arguments:",
+				"  ENABLED  Bool",
+				"",
+				"Options:",
+				"  --ratio, -r  Float (default: 0.50)",
+			},
+		},
+		{
+			name:   "prints help for prefix matched subcommand",
+			args:   []string{"matching", "desc", "--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: matching describe [ITEM]",
+				"",
+				"Arguments:",
+				"  ITEM  String (optional)",
+			},
+		},
+		{
+			name:   "prints subcommand aliases and deprecations",
+			args:   []string{"info", "--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: info (add|delete|remove) ...",
+				"",
+				"Subcommands:",
+				"  add, new, create",
+				"  delete, rm",
+				`  remove            (deprecated: use "delete" instead)`,
+			},
+		},
+		{
+			name: "prints percent signs verbatim",
+			cmd: &TerminusCommand{
+				Description: "Sets the confidence.",
+				Flags: []Flag{
+					BoolFlag("sure", 's', Description("100% sure")),
+				},
+			},
+			args:   []string{"--help"},
+			wantOK: true,
+			wantStdout: []string{
+				"Usage: [OPTIONS]",
+				"",
+				"Sets the confidence.",
+				"",
+				"Options:",
+				"  --sure, -s    100% sure",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldGetenv := getenv
+			getenv = func(string) string { return "" }
+			defer func() { getenv = oldGetenv }()
+
+			cmd := test.cmd
+			if cmd == nil {
+				cmd = branchCommand(NoopExecutor, &Completor{})
+			}
+			tcos := &TestCommandOS{}
+			_, err := Execute(tcos, cmd, test.args, nil)
+			if ok := err == nil; ok != test.wantOK {
+				t.Errorf("commands.Execute(%v) returned %v for ok; want %v", test.args, ok, test.wantOK)
+			}
+			if diff := cmp.Diff(test.wantStdout, tcos.GetStdout()); diff != "" {
+				t.Errorf("command.Execute(%v) produced stdout diff (-want, +got):\n%s", test.args, diff)
+			}
+			if diff := cmp.Diff([]string(nil), tcos.GetStderr()); diff != "" {
+				t.Errorf("command.Execute(%v) produced stderr diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestHelpWithName(t *testing.T) {
+	tcos := &TestCommandOS{}
+	PrintHelp(tcos, "mycli", branchCommand(NoopExecutor, &Completor{}), []string{"mw", "--help"})
+	want := []string{
+		"Usage: mycli mw ALPHA ALPHA",
+		"",
+		"Arguments:",
+		"  ALPHA  StringList (2)",
+	}
+	if diff := cmp.Diff(want, tcos.GetStdout()); diff != "" {
+		t.Errorf("PrintHelp() produced stdout diff (-want, +got):\n%s", diff)
+	}
+}
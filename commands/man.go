@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// manEscape escapes the roff control characters in s.
+func manEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
+
+// manText returns s escaped as a line of roff text.
+func manText(s string) string {
+	s = manEscape(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		return `\&` + s
+	}
+	return s
+}
+
+// manSynopsis returns the synopsis lines for the command at the given path
+// with the path in bold.
+func manSynopsis(path []string, cu *CommandUsage) []string {
+	lines := []string{".nf"}
+	p := strings.Join(path, " ")
+	for _, s := range cu.synopsis(path) {
+		lines = append(lines, fmt.Sprintf(`\fB%s\fR%s`, manEscape(p), manEscape(strings.TrimPrefix(s, p))))
+	}
+	return append(lines, ".fi")
+}
+
+// manEntry returns a tagged paragraph for an argument or flag.
+func manEntry(tag string, au *ArgUsage) []string {
+	lines := []string{".TP", tag}
+	var desc []string
+	for _, d := range []string{au.Description, au.description()} {
+		if d != "" {
+			desc = append(desc, d)
+		}
+	}
+	desc = append(desc, au.Validators...)
+	for i, d := range desc {
+		if i > 0 {
+			lines = append(lines, ".br")
+		}
+		lines = append(lines, manText(d))
+	}
+	return lines
+}
+
+// manArgs returns the entries for the positional arguments of a command.
+func manArgs(cu *CommandUsage) []string {
+	var lines []string
+	for _, a := range cu.Args {
+		lines = append(lines, manEntry(fmt.Sprintf(`\fI%s\fR`, manEscape(a.placeholder())), a)...)
+	}
+	return lines
+}
+
+// manFlags returns the entries for the flags of a command.
+func manFlags(cu *CommandUsage) []string {
+	var lines []string
+	for _, f := range cu.Flags {
+		names := []string{fmt.Sprintf(`\fB%s\fR`, manEscape("--"+f.Name))}
+		if f.ShortName != 0 {
+			names = append(names, fmt.Sprintf(`\fB%s\fR`, manEscape(fmt.Sprintf("-%c", f.ShortName))))
+		}
+		tag := strings.Join(names, ", ")
+		if vt := f.valueTokens(); len(vt) > 0 {
+			tag = fmt.Sprintf(`%s \fI%s\fR`, tag, manEscape(strings.Join(vt, " ")))
+		}
+		lines = append(lines, manEntry(tag, f)...)
+	}
+	return lines
+}
+
+// manConstraints returns a bulleted list of the flag constraints of a
+// command.
+func manConstraints(cu *CommandUsage) []string {
+	var lines []string
+	for _, c := range cu.Constraints {
+		lines = append(lines, `.IP \(bu 2`, manText(c))
+	}
+	return lines
+}
+
+// manSection returns a section with the given title and body (or nothing if
+// the body is empty).
+func manSection(title string, body []string) []string {
+	if len(body) == 0 {
+		return nil
+	}
+	return append([]string{fmt.Sprintf(".SH %s", title)}, body...)
+}
+
+// manSubcommands returns a subsection for every subcommand path below the
+// command at the given path.
+func manSubcommands(path []string, cu *CommandUsage) []string {
+	var lines []string
+	for _, sc := range cu.Subcommands {
+		scPath := append(cp(path), sc.Name)
+		lines = append(lines, fmt.Sprintf(`.SS "%s"`, manEscape(strings.Join(scPath, " "))))
+		if sc.Usage.Description != "" {
+			lines = append(lines, ".PP", manText(sc.Usage.Description))
+		}
+		if len(sc.Aliases) > 0 {
+			lines = append(lines, ".PP", manText(fmt.Sprintf("Aliases: %s", strings.Join(sc.Aliases, ", "))))
+		}
+		if sc.Deprecated != "" {
+			lines = append(lines, ".PP", manText(fmt.Sprintf("Deprecated: %s", sc.Deprecated)))
+		}
+		lines = append(lines, ".PP")
+		lines = append(lines, manSynopsis(scPath, sc.Usage)...)
+		lines = append(lines, manArgs(sc.Usage)...)
+		lines = append(lines, manFlags(sc.Usage)...)
+		if cs := manConstraints(sc.Usage); len(cs) > 0 {
+			lines = append(lines, ".PP", "Constraints:")
+			lines = append(lines, cs...)
+		}
+		lines = append(lines, manSubcommands(scPath, sc.Usage)...)
+	}
+	return lines
+}
+
+// ManPage returns the lines of a roff man page (section 1) for the CLI with
+// the given name. The page documents the CLI's arguments and flags and has a
+// subsection for every subcommand path (e.g. "mycli remote add"). Hidden
+// subcommands are omitted.
+func ManPage(name string, c Command) []string {
+	cu := c.StructuredUsage()
+	path := []string{name}
+
+	lines := []string{fmt.Sprintf(`.TH "%s" "1"`, manEscape(strings.ToUpper(name)))}
+	title := name
+	if cu.Description != "" {
+		title = fmt.Sprintf("%s - %s", name, cu.Description)
+	}
+	lines = append(lines, manSection("NAME", []string{manText(title)})...)
+	lines = append(lines, manSection("SYNOPSIS", manSynopsis(path, cu))...)
+	lines = append(lines, manSection("ARGUMENTS", manArgs(cu))...)
+	lines = append(lines, manSection("OPTIONS", manFlags(cu))...)
+	lines = append(lines, manSection("CONSTRAINTS", manConstraints(cu))...)
+	return append(lines, manSection("COMMANDS", manSubcommands(path, cu))...)
+}
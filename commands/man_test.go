@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestManPage(t *testing.T) {
+	cmd := &CommandBranch{
+		Description: "Manages my files.",
+		TerminusCommand: &TerminusCommand{
+			Args: []Arg{
+				StringArg("query", false, nil, Description("Files to search for.")),
+			},
+			Flags: []Flag{
+				BoolFlag("verbose", 'v'),
+			},
+		},
+		Subcommands: map[string]Command{
+			"add": &TerminusCommand{
+				Description: "Adds files.",
+				Args: []Arg{
+					StringListArg("files", 1, 2, nil),
+				},
+				Flags: []Flag{
+					IntFlag("retries", 'r', nil, IntNonNegative(), IntLT(10), Default(IntValue(3)), Description("Number of times to retry.")),
+					StringFlag("message", 0, nil),
+					StringFlag("file", 'F', nil),
+				},
+				FlagConstraints: []FlagConstraint{
+					ExclusiveFlags("message", "file"),
+				},
+			},
+			"debug": &TerminusCommand{},
+			"remote": &CommandBranch{
+				Subcommands: map[string]Command{
+					"add": &TerminusCommand{
+						Args: []Arg{
+							StringArg("url", true, nil, MinLength(4)),
+						},
+					},
+					"rm": &TerminusCommand{},
+				},
+				SubcommandInfo: map[string]*SubcommandInfo{
+					"rm": {Deprecated: `use "remove" instead`},
+				},
+			},
+		},
+		SubcommandInfo: map[string]*SubcommandInfo{
+			"add":   {Aliases: []string{"a"}},
+			"debug": {Hidden: true},
+		},
+	}
+
+	want := []string{
+		`.TH "MY\-CLI" "1"`,
+		".SH NAME",
+		`my\-cli \- Manages my files.`,
+		".SH SYNOPSIS",
+		".nf",
+		`\fBmy\-cli\fR (add|remote) ...`,
+		`\fBmy\-cli\fR [QUERY] [OPTIONS]`,
+		".fi",
+		".SH ARGUMENTS",
+		".TP",
+		`\fIQUERY\fR`,
+		"Files to search for.",
+		".br",
+		"String (optional)",
+		".SH OPTIONS",
+		".TP",
+		`\fB\-\-verbose\fR, \fB\-v\fR`,
+		".SH COMMANDS",
+		`.SS "my\-cli add"`,
+		".PP",
+		"Adds files.",
+		".PP",
+		"Aliases: a",
+		".PP",
+		".nf",
+		`\fBmy\-cli add\fR FILES [FILES FILES] [OPTIONS]`,
+		".fi",
+		".TP",
+		`\fIFILES\fR`,
+		`StringList (1\-3)`,
+		".TP",
+		`\fB\-\-retries\fR, \fB\-r\fR \fIRETRIES\fR`,
+		"Number of times to retry.",
+		".br",
+		"Int (default: 3)",
+		".br",
+		`must be non\-negative`,
+		".br",
+		"must be less than 10",
+		".TP",
+		`\fB\-\-message\fR \fIMESSAGE\fR`,
+		"String",
+		".TP",
+		`\fB\-\-file\fR, \fB\-F\fR \fIFILE\fR`,
+		"String",
+		".PP",
+		"Constraints:",
+		`.IP \(bu 2`,
+		`\-\-message, \-\-file are mutually exclusive`,
+		`.SS "my\-cli remote"`,
+		".PP",
+		".nf",
+		`\fBmy\-cli remote\fR (add|rm) ...`,
+		".fi",
+		`.SS "my\-cli remote add"`,
+		".PP",
+		".nf",
+		`\fBmy\-cli remote add\fR URL`,
+		".fi",
+		".TP",
+		`\fIURL\fR`,
+		"String",
+		".br",
+		"must be at least 4 characters",
+		`.SS "my\-cli remote rm"`,
+		".PP",
+		`Deprecated: use "remove" instead`,
+		".PP",
+		".nf",
+		`\fBmy\-cli remote rm\fR`,
+		".fi",
+	}
+	if diff := cmp.Diff(want, ManPage("my-cli", cmd)); diff != "" {
+		t.Errorf("ManPage() returned diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestManText(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"--flag", `\-\-flag`},
+		{`back\slash`, `back\eslash`},
+		{".hidden", `\&.hidden`},
+		{"'quoted'", `\&'quoted'`},
+	} {
+		if got := manText(test.s); got != test.want {
+			t.Errorf("manText(%q) returned %q; want %q", test.s, got, test.want)
+		}
+	}
+}
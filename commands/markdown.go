@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// markdownAnchor returns the anchor that markdown renderers (e.g. GitHub)
+// generate for a heading.
+func markdownAnchor(heading string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			sb.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// markdownLink returns a link to the section of the command at the given
+// path.
+func markdownLink(path []string) string {
+	heading := strings.Join(path, " ")
+	return fmt.Sprintf("[%s](#%s)", heading, markdownAnchor(heading))
+}
+
+// markdownCode returns s as inline code (or nothing if s is empty).
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return fmt.Sprintf("`%s`", s)
+}
+
+// markdownTable returns a table with the given header and rows (or nothing
+// if there are no rows).
+func markdownTable(header []string, rows [][]string) []string {
+	if len(rows) == 0 {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("| %s |", strings.Join(header, " | ")),
+		fmt.Sprintf("|%s", strings.Repeat(" --- |", len(header))),
+	}
+	for _, r := range rows {
+		cells := make([]string, 0, len(r))
+		for _, c := range r {
+			cells = append(cells, strings.ReplaceAll(c, "|", `\|`))
+		}
+		lines = append(lines, fmt.Sprintf("| %s |", strings.Join(cells, " | ")))
+	}
+	return lines
+}
+
+// markdownType returns the type of the argument and, for lists, the number of
+// values it takes.
+func (au *ArgUsage) markdownType() string {
+	switch au.Type {
+	case StringListType, IntListType, FloatListType:
+		return au.typeDescription()
+	}
+	return typeToString[au.Type]
+}
+
+// markdownRequired returns whether the argument must be provided.
+func (au *ArgUsage) markdownRequired() string {
+	if (au.Flag && au.Required) || (!au.Flag && au.MinN > 0) {
+		return "yes"
+	}
+	return "no"
+}
+
+// markdownDefault returns the argument's default value as inline code.
+func (au *ArgUsage) markdownDefault() string {
+	if au.Default == nil {
+		return ""
+	}
+	return markdownCode(au.Default.Str())
+}
+
+// markdownCommand returns the blocks of the section for the command at the
+// given path (excluding the command's description).
+func markdownCommand(path []string, cu *CommandUsage) [][]string {
+	blocks := [][]string{
+		append(append([]string{"```"}, cu.synopsis(path)...), "```"),
+	}
+
+	if len(cu.Subcommands) > 0 {
+		subcommands := make([]string, 0, len(cu.Subcommands))
+		for _, sc := range cu.Subcommands {
+			item := fmt.Sprintf("- %s", markdownLink(append(cp(path), sc.Name)))
+			if sc.Usage.Description != "" {
+				item = fmt.Sprintf("%s: %s", item, sc.Usage.Description)
+			}
+			subcommands = append(subcommands, item)
+		}
+		blocks = append(blocks, []string{"**Subcommands**"}, subcommands)
+	}
+
+	args := make([][]string, 0, len(cu.Args))
+	for _, a := range cu.Args {
+		args = append(args, []string{
+			markdownCode(a.placeholder()),
+			a.markdownType(),
+			a.markdownRequired(),
+			a.markdownDefault(),
+			strings.Join(a.Validators, "; "),
+			a.Description,
+		})
+	}
+	if len(args) > 0 {
+		blocks = append(blocks, []string{"**Arguments**"}, markdownTable([]string{"Name", "Type", "Required", "Default", "Validators", "Description"}, args))
+	}
+
+	flags := make([][]string, 0, len(cu.Flags))
+	for _, f := range cu.Flags {
+		var short string
+		if f.ShortName != 0 {
+			short = markdownCode(fmt.Sprintf("-%c", f.ShortName))
+		}
+		flags = append(flags, []string{
+			markdownCode(fmt.Sprintf("--%s", f.Name)),
+			short,
+			f.markdownType(),
+			f.markdownRequired(),
+			f.markdownDefault(),
+			strings.Join(f.Validators, "; "),
+			f.Description,
+		})
+	}
+	if len(flags) > 0 {
+		blocks = append(blocks, []string{"**Flags**"}, markdownTable([]string{"Name", "Short name", "Type", "Required", "Default", "Validators", "Description"}, flags))
+	}
+
+	if len(cu.Constraints) > 0 {
+		constraints := make([]string, 0, len(cu.Constraints))
+		for _, c := range cu.Constraints {
+			constraints = append(constraints, fmt.Sprintf("- %s", c))
+		}
+		blocks = append(blocks, []string{"**Constraints**"}, constraints)
+	}
+	return blocks
+}
+
+// markdownSubcommands returns the table of contents entries and the sections
+// for every subcommand path below the command at the given path.
+func markdownSubcommands(path []string, cu *CommandUsage) ([]string, [][]string) {
+	var contents []string
+	var blocks [][]string
+	for _, sc := range cu.Subcommands {
+		scPath := append(cp(path), sc.Name)
+		contents = append(contents, fmt.Sprintf("%s- %s", strings.Repeat("  ", len(path)-1), markdownLink(scPath)))
+
+		blocks = append(blocks, []string{fmt.Sprintf("## %s", strings.Join(scPath, " "))})
+		if sc.Usage.Description != "" {
+			blocks = append(blocks, []string{sc.Usage.Description})
+		}
+		if len(sc.Aliases) > 0 {
+			aliases := make([]string, 0, len(sc.Aliases))
+			for _, a := range sc.Aliases {
+				aliases = append(aliases, markdownCode(a))
+			}
+			blocks = append(blocks, []string{fmt.Sprintf("Aliases: %s", strings.Join(aliases, ", "))})
+		}
+		if sc.Deprecated != "" {
+			blocks = append(blocks, []string{fmt.Sprintf("**Deprecated:** %s", sc.Deprecated)})
+		}
+		blocks = append(blocks, markdownCommand(scPath, sc.Usage)...)
+
+		c, b := markdownSubcommands(scPath, sc.Usage)
+		contents = append(contents, c...)
+		blocks = append(blocks, b...)
+	}
+	return contents, blocks
+}
+
+// MarkdownReference returns the lines of a Markdown reference for the CLI
+// with the given name. The reference starts with a table of contents of every
+// subcommand path, followed by a section for each command with its synopsis
+// and tables of its arguments and flags. Hidden subcommands are omitted.
+func MarkdownReference(name string, c Command) []string {
+	cu := c.StructuredUsage()
+	path := []string{name}
+
+	blocks := [][]string{{fmt.Sprintf("# %s", name)}}
+	if cu.Description != "" {
+		blocks = append(blocks, []string{cu.Description})
+	}
+	contents, subcommands := markdownSubcommands(path, cu)
+	if len(contents) > 0 {
+		blocks = append(blocks, []string{"**Contents**"}, contents)
+	}
+	blocks = append(blocks, markdownCommand(path, cu)...)
+	blocks = append(blocks, subcommands...)
+
+	var lines []string
+	for i, b := range blocks {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, b...)
+	}
+	return lines
+}
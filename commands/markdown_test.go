@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMarkdownReference(t *testing.T) {
+	cmd := &CommandBranch{
+		Description: "Manages my files.",
+		TerminusCommand: &TerminusCommand{
+			Args: []Arg{
+				StringArg("query", false, nil, Description("Files to search for.")),
+			},
+			Flags: []Flag{
+				BoolFlag("verbose", 'v'),
+			},
+		},
+		Subcommands: map[string]Command{
+			"add": &TerminusCommand{
+				Description: "Adds files.",
+				Args: []Arg{
+					StringListArg("files", 1, 2, nil),
+				},
+				Flags: []Flag{
+					IntFlag("retries", 'r', nil, IntNonNegative(), IntLT(10), Default(IntValue(3)), Description("Number of times to retry.")),
+					StringFlag("message", 0, nil),
+					StringFlag("file", 'F', nil),
+				},
+				FlagConstraints: []FlagConstraint{
+					ExclusiveFlags("message", "file"),
+				},
+			},
+			"debug": &TerminusCommand{},
+			"remote": &CommandBranch{
+				Subcommands: map[string]Command{
+					"add": &TerminusCommand{
+						Args: []Arg{
+							StringArg("url", true, nil, MinLength(4)),
+						},
+					},
+					"rm": &TerminusCommand{},
+				},
+				SubcommandInfo: map[string]*SubcommandInfo{
+					"rm": {Deprecated: `use "remove" instead`},
+				},
+			},
+		},
+		SubcommandInfo: map[string]*SubcommandInfo{
+			"add":   {Aliases: []string{"a"}},
+			"debug": {Hidden: true},
+		},
+	}
+
+	want := []string{
+		"# my-cli",
+		"",
+		"Manages my files.",
+		"",
+		"**Contents**",
+		"",
+		"- [my-cli add](#my-cli-add)",
+		"- [my-cli remote](#my-cli-remote)",
+		"  - [my-cli remote add](#my-cli-remote-add)",
+		"  - [my-cli remote rm](#my-cli-remote-rm)",
+		"",
+		"```",
+		"my-cli (add|remote) ...",
+		"my-cli [QUERY] [OPTIONS]",
+		"```",
+		"",
+		"**Subcommands**",
+		"",
+		"- [my-cli add](#my-cli-add): Adds files.",
+		"- [my-cli remote](#my-cli-remote)",
+		"",
+		"**Arguments**",
+		"",
+		"| Name | Type | Required | Default | Validators | Description |",
+		"| --- | --- | --- | --- | --- | --- |",
+		"| `QUERY` | String | no |  |  | Files to search for. |",
+		"",
+		"**Flags**",
+		"",
+		"| Name | Short name | Type | Required | Default | Validators | Description |",
+		"| --- | --- | --- | --- | --- | --- | --- |",
+		"| `--verbose` | `-v` | Bool | no |  |  |  |",
+		"",
+		"## my-cli add",
+		"",
+		"Adds files.",
+		"",
+		"Aliases: `a`",
+		"",
+		"```",
+		"my-cli add FILES [FILES FILES] [OPTIONS]",
+		"```",
+		"",
+		"**Arguments**",
+		"",
+		"| Name | Type | Required | Default | Validators | Description |",
+		"| --- | --- | --- | --- | --- | --- |",
+		"| `FILES` | StringList (1-3) | yes |  |  |  |",
+		"",
+		"**Flags**",
+		"",
+		"| Name | Short name | Type | Required | Default | Validators | Description |",
+		"| --- | --- | --- | --- | --- | --- | --- |",
+		"| `--retries` | `-r` | Int | no | `3` | must be non-negative; must be less than 10 | Number of times to retry. |",
+		"| `--message` |  | String | no |  |  |  |",
+		"| `--file` | `-F` | String | no |  |  |  |",
+		"",
+		"**Constraints**",
+		"",
+		"- --message, --file are mutually exclusive",
+		"",
+		"## my-cli remote",
+		"",
+		"```",
+		"my-cli remote (add|rm) ...",
+		"```",
+		"",
+		"**Subcommands**",
+		"",
+		"- [my-cli remote add](#my-cli-remote-add)",
+		"- [my-cli remote rm](#my-cli-remote-rm)",
+		"",
+		"## my-cli remote add",
+		"",
+		"```",
+		"my-cli remote add URL",
+		"```",
+		"",
+		"**Arguments**",
+		"",
+		"| Name | Type | Required | Default | Validators | Description |",
+		"| --- | --- | --- | --- | --- | --- |",
+		"| `URL` | String | yes |  | must be at least 4 characters |  |",
+		"",
+		"## my-cli remote rm",
+		"",
+		`**Deprecated:** use "remove" instead`,
+		"",
+		"```",
+		"my-cli remote rm",
+		"```",
+	}
+	if diff := cmp.Diff(want, MarkdownReference("my-cli", cmd)); diff != "" {
+		t.Errorf("MarkdownReference() returned diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	for _, test := range []struct {
+		heading string
+		want    string
+	}{
+		{"my-cli add", "my-cli-add"},
+		{"MyCLI remote_add", "mycli-remote_add"},
+		{"cli (v2.0)", "cli-v20"},
+	} {
+		if got := markdownAnchor(test.heading); got != test.want {
+			t.Errorf("markdownAnchor(%q) returned %q; want %q", test.heading, got, test.want)
+		}
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"strings"
 )
 
-// TODO: short boolean flags should be combinable (`grep -or ...` for example)
-
 type singleArgProcessor struct {
 	optional  bool
 	transform func(s string) (*Value, error)
@@ -20,6 +18,12 @@ type singleArgProcessor struct {
 	shortName rune
 }
 
+// newSingleArgProcessor checks the default value of sap (see Default).
+func newSingleArgProcessor(sap *singleArgProcessor) *singleArgProcessor {
+	checkDefault(sap.name, sap.vt, !sap.flag && !sap.optional, sap.opts)
+	return sap
+}
+
 func (sap *singleArgProcessor) set(v *Value, args, flags map[string]*Value) {
 	if v == nil {
 		return
@@ -36,10 +40,13 @@ func (sap *singleArgProcessor) ProcessExecute(s []string) (*Value, int, error) {
 		if sap.optional {
 			return nil, 0, nil
 		}
-		return nil, 0, fmt.Errorf("no argument provided for %q", sap.name)
+		return nil, 0, usageErrorf("no argument provided for %q", sap.name)
 	}
 	v, err := sap.transform(s[0])
-	return v, 1, err
+	if err != nil {
+		return nil, 1, validationError(sap.name, err)
+	}
+	return v, 1, nil
 }
 
 func (sap *singleArgProcessor) ProcessExecuteArgs(rawArgs []string, args, flags map[string]*Value) (int, error) {
@@ -48,14 +55,8 @@ func (sap *singleArgProcessor) ProcessExecuteArgs(rawArgs []string, args, flags
 		return n, err
 	}
 	sap.set(v, args, flags)
-	for _, opt := range sap.opts {
-		if sap.vt != opt.ValueType() {
-			return 0, fmt.Errorf("option can only be bound to arguments with type %v", opt.ValueType())
-		}
-
-		if err := opt.Validate(v); err != nil {
-			return 0, fmt.Errorf("validation failed: %v", err)
-		}
+	if err := validate(sap.name, sap.vt, v, sap.opts); err != nil {
+		return 0, err
 	}
 	return n, err
 }
@@ -71,6 +72,10 @@ func (sap *singleArgProcessor) ProcessCompleteArgs(rawArgs []string, args, flags
 	return n
 }
 
+func (sap *singleArgProcessor) settings() *argSettings {
+	return newArgSettings(sap.opts)
+}
+
 func (sap *singleArgProcessor) Name() string {
 	return sap.name
 }
@@ -83,9 +88,9 @@ func (sap *singleArgProcessor) Optional() bool {
 	return sap.optional
 }
 
-func (sap *singleArgProcessor) Complete(rawValue string, args, flags map[string]*Value) *Completion {
+func (sap *singleArgProcessor) Complete(rawValue string, args, flags map[string]*Value) (*Completion, error) {
 	if sap.completor == nil {
-		return nil
+		return nil, nil
 	}
 	var v *Value
 	if sap.flag {
@@ -108,20 +113,32 @@ type listArgProcessor struct {
 	flag      bool
 }
 
-func (lap *listArgProcessor) set(v *Value, args, flags map[string]*Value) {
+// newListArgProcessor checks the default value of lap (see Default).
+func newListArgProcessor(lap *listArgProcessor) *listArgProcessor {
+	checkDefault(lap.name, lap.vt, !lap.flag && lap.minN > 0, lap.opts)
+	return lap
+}
+
+// set stores v and returns the stored value. The values of repeatable flags
+// are appended to the values from previous occurrences of the flag.
+func (lap *listArgProcessor) set(v *Value, args, flags map[string]*Value) *Value {
 	if v == nil {
-		return
+		return nil
 	}
+	values := args
 	if lap.flag {
-		flags[lap.name] = v
-	} else {
-		args[lap.name] = v
+		values = flags
+	}
+	if prev, ok := values[lap.name]; ok && lap.flag && lap.settings().repeatable {
+		v = prev.appendList(v)
 	}
+	values[lap.name] = v
+	return v
 }
 
 func (lap *listArgProcessor) ProcessExecute(s []string) (*Value, int, error) {
 	if len(s) < lap.minN {
-		return nil, len(s), fmt.Errorf("not enough arguments provided for %q", lap.name)
+		return nil, len(s), usageErrorf("not enough arguments provided for %q", lap.name)
 	}
 	if len(s) == 0 {
 		return nil, 0, nil
@@ -133,24 +150,25 @@ func (lap *listArgProcessor) ProcessExecute(s []string) (*Value, int, error) {
 		endIdx = min(lap.minN+lap.optionalN, len(s))
 	}
 	v, err := lap.transform(s[:endIdx])
-	return v, endIdx, err
+	if err != nil {
+		return nil, endIdx, validationError(lap.name, err)
+	}
+	return v, endIdx, nil
 }
 
 func (lap *listArgProcessor) ProcessExecuteArgs(rawArgs []string, args, flags map[string]*Value) (int, error) {
 	v, n, err := lap.ProcessExecute(cp(rawArgs))
-	lap.set(v, args, flags)
-	for _, opt := range lap.opts {
-		if lap.vt != opt.ValueType() {
-			return 0, fmt.Errorf("option can only be bound to arguments with type %v", opt.ValueType())
-		}
-
-		if err := opt.Validate(v); err != nil {
-			return 0, fmt.Errorf("validation failed: %v", err)
-		}
+	v = lap.set(v, args, flags)
+	if err := validate(lap.name, lap.vt, v, lap.opts); err != nil {
+		return 0, err
 	}
 	return n, err
 }
 
+func (lap *listArgProcessor) settings() *argSettings {
+	return newArgSettings(lap.opts)
+}
+
 func (lap *listArgProcessor) Name() string {
 	return lap.name
 }
@@ -163,9 +181,9 @@ func (lap *listArgProcessor) Optional() bool {
 	return lap.minN == 0
 }
 
-func (lap *listArgProcessor) Complete(rawValue string, args, flags map[string]*Value) *Completion {
+func (lap *listArgProcessor) Complete(rawValue string, args, flags map[string]*Value) (*Completion, error) {
 	if lap.completor == nil {
-		return nil
+		return nil, nil
 	}
 	var v *Value
 	if lap.flag {
@@ -177,38 +195,25 @@ func (lap *listArgProcessor) Complete(rawValue string, args, flags map[string]*V
 }
 
 func (lap *listArgProcessor) Usage() []string {
-	ln := lap.minN
-	if lap.optionalN == UnboundedList {
-		ln += 1
-	} else {
-		ln += lap.optionalN
-	}
-
-	usage := make([]string, 0, ln)
-	n := strings.ReplaceAll(strings.ToUpper(lap.name), " ", "_")
-	if lap.flag {
-		n = "FLAG_VALUE"
-		if lap.shortName == 0 {
-			usage = append(usage, fmt.Sprintf("--%s", lap.name))
-		} else {
-			usage = append(usage, fmt.Sprintf("--%s|-%s", lap.name, string(lap.shortName)))
-		}
-	}
-
-	for idx := 0; idx < lap.minN; idx++ {
-		usage = append(usage, n)
-	}
+	return lap.StructuredUsage().usage()
+}
 
-	if lap.optionalN == UnboundedList {
-		usage = append(usage, fmt.Sprintf("[%s ...]", n))
-	} else if lap.optionalN > 0 {
-		usage = append(usage, "[")
-		for idx := 0; idx < lap.optionalN; idx++ {
-			usage = append(usage, n)
-		}
-		usage = append(usage, "]")
+func (lap *listArgProcessor) StructuredUsage() *ArgUsage {
+	as := lap.settings()
+	return &ArgUsage{
+		Name:        lap.name,
+		ShortName:   lap.shortName,
+		Flag:        lap.flag,
+		Type:        lap.vt,
+		MinN:        lap.minN,
+		OptionalN:   lap.optionalN,
+		Default:     as.defaultValue,
+		EnvVar:      as.envVar,
+		ConfigKey:   as.configKey,
+		Validators:  validatorDescriptions(lap.opts),
+		Description: as.description,
+		Repeatable:  lap.flag && as.repeatable,
 	}
-	return usage
 }
 
 func (lap *listArgProcessor) ProcessCompleteArgs(rawArgs []string, args, flags map[string]*Value) int {
@@ -232,27 +237,69 @@ func (lap *listArgProcessor) ProcessComplete(s []string) (*Value, int) {
 }
 
 func (sap *singleArgProcessor) Usage() []string {
-	if sap.flag {
-		if sap.shortName == 0 {
-			return []string{fmt.Sprintf("--%s", sap.name), "FLAG_VALUE"}
-		}
-		return []string{fmt.Sprintf("--%s|-%s", sap.name, string(sap.shortName)), "FLAG_VALUE"}
-	} else if sap.optional {
-		return []string{"[", strings.ToUpper(sap.name), "]"}
+	return sap.StructuredUsage().usage()
+}
+
+func (sap *singleArgProcessor) StructuredUsage() *ArgUsage {
+	as := sap.settings()
+	au := &ArgUsage{
+		Name:        sap.name,
+		ShortName:   sap.shortName,
+		Flag:        sap.flag,
+		Type:        sap.vt,
+		MinN:        1,
+		Default:     as.defaultValue,
+		EnvVar:      as.envVar,
+		ConfigKey:   as.configKey,
+		Validators:  validatorDescriptions(sap.opts),
+		Description: as.description,
+	}
+	if sap.optional && !sap.flag {
+		au.MinN = 0
+		au.OptionalN = 1
 	}
-	return []string{strings.ToUpper(sap.name)}
+	return au
 }
 
 type boolFlagProcessor struct {
 	name      string
 	shortName rune
+	opts      []ArgOpt
+	// negated is true for the "--no-<name>" flag, which sets the flag to false.
+	negated bool
+}
+
+// negation returns the flag that sets bfp to false.
+func (bfp *boolFlagProcessor) negation() *boolFlagProcessor {
+	return &boolFlagProcessor{
+		name:    bfp.name,
+		negated: true,
+	}
 }
 
 func (bfp *boolFlagProcessor) ProcessExecuteArgs(rawArgs []string, args, flags map[string]*Value) (int, error) {
-	flags[bfp.name] = BoolValue(true)
+	flags[bfp.name] = BoolValue(!bfp.negated)
 	return 0, nil
 }
 
+// processValue sets the flag to an explicitly provided value (e.g.
+// "--name=false").
+func (bfp *boolFlagProcessor) processValue(rawValue string, flags map[string]*Value) error {
+	if bfp.negated {
+		return usageErrorf("flag %q does not take a value", fmt.Sprintf("no-%s", bfp.name))
+	}
+	b, ok := boolStringMap[strings.ToLower(rawValue)]
+	if !ok {
+		return validationError(bfp.name, fmt.Errorf("argument should be a bool: %q", rawValue))
+	}
+	flags[bfp.name] = BoolValue(b)
+	return nil
+}
+
+func (bfp *boolFlagProcessor) settings() *argSettings {
+	return newArgSettings(bfp.opts)
+}
+
 func (bfp *boolFlagProcessor) Name() string {
 	return bfp.name
 }
@@ -261,18 +308,90 @@ func (bfp *boolFlagProcessor) ShortName() rune {
 	return bfp.shortName
 }
 
-func (bfp *boolFlagProcessor) Complete(rawValue string, args, flags map[string]*Value) *Completion {
-	return nil
+func (bfp *boolFlagProcessor) Complete(rawValue string, args, flags map[string]*Value) (*Completion, error) {
+	if bfp.negated {
+		return nil, nil
+	}
+	return BoolCompletor().Complete(rawValue, flags[bfp.name], args, flags)
 }
 
 func (bfp *boolFlagProcessor) Usage() []string {
-	if bfp.shortName != 0 {
-		return []string{fmt.Sprintf("--%s|-%s", bfp.name, string(bfp.shortName))}
+	return bfp.StructuredUsage().usage()
+}
+
+func (bfp *boolFlagProcessor) StructuredUsage() *ArgUsage {
+	as := bfp.settings()
+	return &ArgUsage{
+		Name:        bfp.name,
+		ShortName:   bfp.shortName,
+		Flag:        true,
+		Type:        BoolType,
+		Default:     as.defaultValue,
+		EnvVar:      as.envVar,
+		ConfigKey:   as.configKey,
+		Validators:  validatorDescriptions(bfp.opts),
+		Description: as.description,
 	}
-	return []string{fmt.Sprintf("--%s", bfp.name)}
 }
 
 func (bfp *boolFlagProcessor) ProcessCompleteArgs(rawArgs []string, args, flags map[string]*Value) int {
-	flags[bfp.name] = BoolValue(true)
+	flags[bfp.name] = BoolValue(!bfp.negated)
 	return 0
 }
+
+type countFlagProcessor struct {
+	name      string
+	shortName rune
+	opts      []ArgOpt
+}
+
+// increment adds one to the number of occurrences of the flag.
+func (cfp *countFlagProcessor) increment(flags map[string]*Value) {
+	flags[cfp.name] = IntValue(flags[cfp.name].Int() + 1)
+}
+
+func (cfp *countFlagProcessor) ProcessExecuteArgs(rawArgs []string, args, flags map[string]*Value) (int, error) {
+	cfp.increment(flags)
+	return 0, nil
+}
+
+func (cfp *countFlagProcessor) ProcessCompleteArgs(rawArgs []string, args, flags map[string]*Value) int {
+	cfp.increment(flags)
+	return 0
+}
+
+func (cfp *countFlagProcessor) settings() *argSettings {
+	return newArgSettings(cfp.opts)
+}
+
+func (cfp *countFlagProcessor) Name() string {
+	return cfp.name
+}
+
+func (cfp *countFlagProcessor) ShortName() rune {
+	return cfp.shortName
+}
+
+func (cfp *countFlagProcessor) Complete(rawValue string, args, flags map[string]*Value) (*Completion, error) {
+	return nil, nil
+}
+
+func (cfp *countFlagProcessor) Usage() []string {
+	return cfp.StructuredUsage().usage()
+}
+
+func (cfp *countFlagProcessor) StructuredUsage() *ArgUsage {
+	as := cfp.settings()
+	return &ArgUsage{
+		Name:        cfp.name,
+		ShortName:   cfp.shortName,
+		Flag:        true,
+		Type:        IntType,
+		Default:     as.defaultValue,
+		EnvVar:      as.envVar,
+		ConfigKey:   as.configKey,
+		Validators:  validatorDescriptions(cfp.opts),
+		Description: as.description,
+		Repeatable:  true,
+	}
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// configValue converts a value from the config file to flag values.
+func configValue(v interface{}) ([]string, error) {
+	switch t := v.(type) {
+	case string:
+		return []string{t}, nil
+	case float64:
+		return []string{strconv.FormatFloat(t, 'f', -1, 64)}, nil
+	case bool:
+		return []string{strconv.FormatBool(t)}, nil
+	case []interface{}:
+		var values []string
+		for _, e := range t {
+			if _, ok := e.([]interface{}); ok {
+				return nil, fmt.Errorf("nested lists aren't supported")
+			}
+			ev, err := configValue(e)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, ev...)
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+// LoadConfig reads the config file at path (see Option.ConfigFile). A
+// missing file is treated as an empty config.
+func LoadConfig(path string) (map[string][]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal config file: %v", err)
+	}
+	config := map[string][]string{}
+	for k, v := range raw {
+		values, err := configValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for config key %q: %v", k, err)
+		}
+		config[k] = values
+	}
+	return config, nil
+}
+
+// RunSetup loads opt.ConfigFile, runs opt.SetupCommand and returns the
+// OptionInfo that should be passed to the command. The output of the setup
+// command is written to a temporary file (OptionInfo.SetupOutputFile) that is
+// removed when the returned cleanup function is called. An error is returned
+// if the config file is invalid or if the setup command exits with a non-zero
+// status.
+func RunSetup(opt *Option) (*OptionInfo, func(), error) {
+	oi := &OptionInfo{}
+	noop := func() {}
+	if opt == nil {
+		return oi, noop, nil
+	}
+
+	if opt.ConfigFile != "" {
+		config, err := LoadConfig(opt.ConfigFile)
+		if err != nil {
+			return nil, noop, err
+		}
+		oi.Config = config
+	}
+
+	if opt.SetupCommand == "" {
+		return oi, noop, nil
+	}
+
+	f, err := ioutil.TempFile("", "commands_setup_*.txt")
+	if err != nil {
+		return nil, noop, fmt.Errorf("failed to create setup output file: %v", err)
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("bash", "-c", opt.SetupCommand)
+	cmd.Stdout = f
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	if cerr := f.Close(); err == nil && cerr != nil {
+		cleanup()
+		return nil, noop, fmt.Errorf("failed to write setup output file: %v", cerr)
+	}
+	if err != nil {
+		cleanup()
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, noop, fmt.Errorf("failed to run setup command: %v: %s", err, msg)
+		}
+		return nil, noop, fmt.Errorf("failed to run setup command: %v", err)
+	}
+
+	oi.SetupOutputFile = f.Name()
+	return oi, cleanup, nil
+}
+
+// ExecuteWithOption runs the setup command of opt and then executes the given
+// unparsed command of the CLI with the given name with the resulting
+// OptionInfo. The setup command is not run if only the help page is
+// requested. Like Execute, any returned error is also written to stderr.
+func ExecuteWithOption(cos CommandOS, name string, c Command, args []string, opt *Option) (*ExecutorResponse, error) {
+	if helpRequested(c, args) {
+		return Execute(cos, c, args, &OptionInfo{Name: name})
+	}
+
+	oi, cleanup, err := RunSetup(opt)
+	if err != nil {
+		cos.Stderr("%v", err)
+		return nil, err
+	}
+	defer cleanup()
+	oi.Name = name
+	return Execute(cos, c, args, oi)
+}
@@ -0,0 +1,179 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExecuteWithOption(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	for _, test := range []struct {
+		name       string
+		opt        *Option
+		args       []string
+		wantOK     bool
+		wantOutput string
+		wantFile   bool
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:   "works with nil option",
+			wantOK: true,
+		},
+		{
+			name:   "works without setup command",
+			opt:    &Option{},
+			wantOK: true,
+		},
+		{
+			name: "writes setup output to file",
+			opt: &Option{
+				SetupCommand: `echo "one two"; echo three`,
+			},
+			wantOK:     true,
+			wantFile:   true,
+			wantOutput: "one two\nthree\n",
+		},
+		{
+			name: "fails if setup command fails",
+			opt: &Option{
+				SetupCommand: "echo some output; echo bad things >&2; exit 3",
+			},
+			wantStderr: []string{"failed to run setup command: exit status 3: bad things"},
+		},
+		{
+			name: "fails without stderr if setup command fails silently",
+			opt: &Option{
+				SetupCommand: "false",
+			},
+			wantStderr: []string{"failed to run setup command: exit status 1"},
+		},
+		{
+			name: "doesn't run setup command for help",
+			opt: &Option{
+				SetupCommand: "exit 1",
+			},
+			args:       []string{"--help"},
+			wantOK:     true,
+			wantStdout: []string{"Usage: cli"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var gotFile, gotOutput string
+			cmd := &TerminusCommand{
+				Executor: func(_ CommandOS, _, _ map[string]*Value, oi *OptionInfo) (*ExecutorResponse, error) {
+					gotFile = oi.SetupOutputFile
+					if gotFile != "" {
+						b, err := ioutil.ReadFile(gotFile)
+						if err != nil {
+							t.Fatalf("failed to read setup output file: %v", err)
+						}
+						gotOutput = string(b)
+					}
+					return nil, nil
+				},
+			}
+
+			tcos := &TestCommandOS{}
+			_, err := ExecuteWithOption(tcos, "cli", cmd, test.args, test.opt)
+			if ok := err == nil; ok != test.wantOK {
+				t.Errorf("ExecuteWithOption() returned %v; want %v", ok, test.wantOK)
+			}
+			if diff := cmp.Diff(test.wantOutput, gotOutput); diff != "" {
+				t.Errorf("ExecuteWithOption() produced setup output diff (-want, +got):\n%s", diff)
+			}
+			if (gotFile != "") != test.wantFile {
+				t.Errorf("ExecuteWithOption() set SetupOutputFile to %q; want set: %v", gotFile, test.wantFile)
+			}
+			if gotFile != "" {
+				if _, err := os.Stat(gotFile); !os.IsNotExist(err) {
+					t.Errorf("ExecuteWithOption() didn't remove setup output file %q", gotFile)
+				}
+			}
+			if diff := cmp.Diff(test.wantStdout, tcos.GetStdout()); diff != "" {
+				t.Errorf("ExecuteWithOption() produced stdout diff (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.wantStderr, tcos.GetStderr()); diff != "" {
+				t.Errorf("ExecuteWithOption() produced stderr diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		contents string
+		noFile   bool
+		want     map[string][]string
+		wantErr  string
+	}{
+		{
+			name:   "missing file is empty config",
+			noFile: true,
+		},
+		{
+			name:     "loads values",
+			contents: `{"name": "abc", "times": 3, "ratio": 0.5, "loud": false, "tags": ["a", 2, true]}`,
+			want: map[string][]string{
+				"name":  {"abc"},
+				"times": {"3"},
+				"ratio": {"0.5"},
+				"loud":  {"false"},
+				"tags":  {"a", "2", "true"},
+			},
+		},
+		{
+			name:     "fails for invalid json",
+			contents: `{`,
+			wantErr:  "failed to unmarshal config file: unexpected end of JSON input",
+		},
+		{
+			name:     "fails for nested objects",
+			contents: `{"nested": {"a": "b"}}`,
+			wantErr:  `invalid value for config key "nested": unsupported value type map[string]interface {}`,
+		},
+		{
+			name:     "fails for nested lists",
+			contents: `{"nested": [["a"]]}`,
+			wantErr:  `invalid value for config key "nested": nested lists aren't supported`,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "commands_config_test")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "config.json")
+			if !test.noFile {
+				if err := ioutil.WriteFile(path, []byte(test.contents), 0644); err != nil {
+					t.Fatalf("failed to write config file: %v", err)
+				}
+			}
+
+			got, err := LoadConfig(path)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr); diff != "" {
+				t.Errorf("LoadConfig() returned error diff (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.want, got, cmpopts.EquateEmpty()); diff != "" {
+				t.Errorf("LoadConfig() returned diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
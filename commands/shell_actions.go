@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	envVarName    = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+	shellFunction = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_-]*$")
+)
+
+// ShellAction is an action that's run by the shell that invoked a command
+// (see ExecutorResponse.Actions and BashWrapper).
+type ShellAction interface {
+	// Bash returns the bash code that performs the action.
+	Bash() (string, error)
+}
+
+// SetEnv sets (and exports) an environment variable.
+type SetEnv struct {
+	Name  string
+	Value string
+}
+
+// Bash returns the bash code that sets the environment variable.
+func (se *SetEnv) Bash() (string, error) {
+	if err := validateEnvVar(se.Name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("export %s=%s", se.Name, quoteBash(se.Value)), nil
+}
+
+// Unset unsets an environment variable.
+type Unset struct {
+	Name string
+}
+
+// Bash returns the bash code that unsets the environment variable.
+func (u *Unset) Bash() (string, error) {
+	if err := validateEnvVar(u.Name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("unset %s", u.Name), nil
+}
+
+// Chdir changes the working directory.
+type Chdir struct {
+	Dir string
+}
+
+// Bash returns the bash code that changes the working directory.
+func (c *Chdir) Bash() (string, error) {
+	if c.Dir == "" {
+		return "", fmt.Errorf("directory must be non-empty")
+	}
+	return fmt.Sprintf("cd -- %s", quoteBash(c.Dir)), nil
+}
+
+// Run runs a command. Every argument is passed to the command literally.
+type Run struct {
+	Args []string
+}
+
+// Bash returns the bash code that runs the command.
+func (r *Run) Bash() (string, error) {
+	if len(r.Args) == 0 {
+		return "", fmt.Errorf("command must be non-empty")
+	}
+	return bashCommand(r.Args), nil
+}
+
+// DefineFunction defines a shell function. Unlike the other actions, Body is
+// bash code that's used as is (e.g. it can refer to the function's arguments
+// with "$@"), so it must not contain untrusted input.
+type DefineFunction struct {
+	Name string
+	Body string
+}
+
+// Bash returns the bash code that defines the function.
+func (df *DefineFunction) Bash() (string, error) {
+	if !shellFunction.MatchString(df.Name) {
+		return "", fmt.Errorf("invalid function name %q", df.Name)
+	}
+	if strings.TrimSpace(df.Body) == "" {
+		return "", fmt.Errorf("function body must be non-empty")
+	}
+	return fmt.Sprintf("function %s {\n%s\n}", df.Name, strings.TrimRight(df.Body, "\n")), nil
+}
+
+func validateEnvVar(name string) error {
+	if !envVarName.MatchString(name) {
+		return fmt.Errorf("invalid environment variable name %q", name)
+	}
+	return nil
+}
+
+// Script returns a bash script that runs all of the response's Actions
+// followed by its Executable.
+func (er *ExecutorResponse) Script() (string, error) {
+	var lines []string
+	for _, a := range er.Actions {
+		line, err := a.Bash()
+		if err != nil {
+			return "", fmt.Errorf("failed to convert action to bash: %v", err)
+		}
+		lines = append(lines, line+"\n")
+	}
+	return strings.Join(lines, "") + ExecutableScript(er.Executable), nil
+}
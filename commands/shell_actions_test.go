@@ -0,0 +1,186 @@
+package commands
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScript(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		resp    *ExecutorResponse
+		want    string
+		wantErr string
+	}{
+		{
+			name: "returns empty script for empty response",
+			resp: &ExecutorResponse{},
+		},
+		{
+			name: "sets environment variable",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&SetEnv{Name: "MY_VAR", Value: "it's $HOME"}},
+			},
+			want: "export MY_VAR='it'\\''s $HOME'\n",
+		},
+		{
+			name: "sets empty environment variable",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&SetEnv{Name: "_v1"}},
+			},
+			want: "export _v1=''\n",
+		},
+		{
+			name: "fails for invalid environment variable name",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&SetEnv{Name: "A=B; rm", Value: "c"}},
+			},
+			wantErr: `failed to convert action to bash: invalid environment variable name "A=B; rm"`,
+		},
+		{
+			name: "unsets environment variable",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&Unset{Name: "MY_VAR"}},
+			},
+			want: "unset MY_VAR\n",
+		},
+		{
+			name: "fails for invalid unset name",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&Unset{Name: "1abc"}},
+			},
+			wantErr: `failed to convert action to bash: invalid environment variable name "1abc"`,
+		},
+		{
+			name: "changes directory",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&Chdir{Dir: "-dir with space"}},
+			},
+			want: "cd -- '-dir with space'\n",
+		},
+		{
+			name: "fails for empty directory",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&Chdir{}},
+			},
+			wantErr: "failed to convert action to bash: directory must be non-empty",
+		},
+		{
+			name: "runs command",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&Run{Args: []string{"echo", "a b", "$c"}}},
+			},
+			want: "'echo' 'a b' '$c'\n",
+		},
+		{
+			name: "fails for empty command",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&Run{}},
+			},
+			wantErr: "failed to convert action to bash: command must be non-empty",
+		},
+		{
+			name: "defines function",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&DefineFunction{Name: "my-func", Body: "  echo \"$@\"\n"}},
+			},
+			want: "function my-func {\n  echo \"$@\"\n}\n",
+		},
+		{
+			name: "fails for invalid function name",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&DefineFunction{Name: "f; rm", Body: "echo"}},
+			},
+			wantErr: `failed to convert action to bash: invalid function name "f; rm"`,
+		},
+		{
+			name: "fails for empty function body",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{&DefineFunction{Name: "f", Body: " \n"}},
+			},
+			wantErr: "failed to convert action to bash: function body must be non-empty",
+		},
+		{
+			name: "runs actions in order followed by executable",
+			resp: &ExecutorResponse{
+				Actions: []ShellAction{
+					&Chdir{Dir: "/tmp"},
+					&SetEnv{Name: "A", Value: "1"},
+					&Unset{Name: "B"},
+					&Run{Args: []string{"ls"}},
+				},
+				Executable: []string{"echo", "done"},
+			},
+			want: strings.Join([]string{
+				"cd -- '/tmp'",
+				"export A='1'",
+				"unset B",
+				"'ls'",
+				"'echo' 'done'",
+				"",
+			}, "\n"),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.resp.Script()
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr); diff != "" {
+				t.Errorf("Script() returned error diff (-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("Script() returned diff (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScriptRuns(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "shell_actions_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "it's here"), 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	resp := &ExecutorResponse{
+		Actions: []ShellAction{
+			&Chdir{Dir: filepath.Join(dir, "it's here")},
+			&SetEnv{Name: "SET_VAR", Value: "one\ntwo $three"},
+			&Unset{Name: "UNSET_VAR"},
+			&Run{Args: []string{"printf", "%s|", "a b", "`c`"}},
+			&DefineFunction{Name: "greet", Body: `printf 'hi %s|' "$1"`},
+			&Run{Args: []string{"greet", "you"}},
+		},
+		Executable: []string{"bash", "-c", `printf '%s|%s|%s|%s' "$(basename "$PWD")" "$SET_VAR" "${UNSET_VAR-unset}" "$1"`, "--", "end"},
+	}
+	script, err := resp.Script()
+	if err != nil {
+		t.Fatalf("Script() returned error: %v", err)
+	}
+
+	cmd := exec.Command("bash", "-c", script)
+	cmd.Env = append(os.Environ(), "UNSET_VAR=value")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("failed to run script: %v", err)
+	}
+	want := []string{"a b", "`c`", "hi you", "it's here", "one\ntwo $three", "unset", "end"}
+	if diff := cmp.Diff(want, strings.Split(string(out), "|")); diff != "" {
+		t.Errorf("script produced diff (-want, +got):\n%s", diff)
+	}
+}
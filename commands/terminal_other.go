@@ -0,0 +1,10 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package commands
+
+// stdoutWidth always returns false since the terminal size can't be queried
+// on this platform.
+func stdoutWidth() (int, bool) {
+	return 0, false
+}
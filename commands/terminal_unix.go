@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package commands
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the terminal size returned by the TIOCGWINSZ ioctl.
+type winsize struct {
+	rows, cols, xPixels, yPixels uint16
+}
+
+// stdoutWidth returns the number of columns of the terminal that stdout is
+// connected to. Returns false if stdout isn't a terminal.
+func stdoutWidth() (int, bool) {
+	ws := &winsize{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, os.Stdout.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(ws))); errno != 0 {
+		return 0, false
+	}
+	return int(ws.cols), true
+}
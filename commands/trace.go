@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"log"
+	"os"
+)
+
+const (
+	// TraceFileEnv is the environment variable that enables completion tracing.
+	// When set, every step of a completion request (which subcommand matched,
+	// which args and flags consumed which words, which fetcher ran and what it
+	// returned) is appended to the file it names. Tracing is written to a file
+	// because stdout is reserved for suggestions.
+	TraceFileEnv = "COMMANDS_TRACE_FILE"
+)
+
+var (
+	// Used for testing.
+	getenv = os.Getenv
+
+	tracer *log.Logger
+)
+
+// startTrace enables tracing if TraceFileEnv is set and returns a function
+// that disables it again.
+func startTrace() func() {
+	path := getenv(TraceFileEnv)
+	if path == "" {
+		return func() {}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return func() {}
+	}
+	tracer = log.New(f, "", 0)
+	return func() {
+		tracer = nil
+		f.Close()
+	}
+}
+
+// tracef writes a line to the trace file if tracing is enabled.
+func tracef(format string, a ...interface{}) {
+	if tracer != nil {
+		tracer.Printf(format, a...)
+	}
+}
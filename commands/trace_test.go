@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type errorFetcher struct{}
+
+func (*errorFetcher) Fetch(_ *Value, _, _ map[string]*Value) (*Completion, error) {
+	return nil, fmt.Errorf("oops")
+}
+
+func TestTrace(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		fetcher    Fetcher
+		args       []string
+		noTrace    bool
+		wantErr    string
+		wantStdout []string
+		want       []string
+	}{
+		{
+			name:    "doesn't trace if env var isn't set",
+			fetcher: &ListFetcher{Options: []string{"one", "two"}},
+			args:    []string{"2", "basic", "o"},
+			noTrace: true,
+			wantStdout: []string{
+				"one",
+			},
+		},
+		{
+			name:    "traces subcommand completion",
+			fetcher: &ListFetcher{Options: []string{"one", "two"}},
+			args:    []string{"1", "b"},
+			wantStdout: []string{
+				"basic",
+				"basically",
+				"beginner",
+			},
+			want: []string{
+				`completing ["b"] with cursor index 1`,
+				`completing subcommands for ["b"]`,
+				`returning suggestions ["basic" "basically" "beginner"]`,
+			},
+		},
+		{
+			name:    "traces args and flags",
+			fetcher: &ListFetcher{Options: []string{"one", "two"}},
+			args:    []string{"4", "basic", "--state", "maine", "o"},
+			wantStdout: []string{
+				"one",
+			},
+			want: []string{
+				`completing ["basic" "--state" "maine" "o"] with cursor index 4`,
+				`matched subcommand "basic"`,
+				`flag "state" consumed ["--state" "maine"]`,
+				`completing arg "val_1" with "o"`,
+				`running fetcher *commands.ListFetcher`,
+				`fetcher returned suggestions ["one" "two"]`,
+				`returning suggestions ["one"]`,
+			},
+		},
+		{
+			name:    "traces consumed args",
+			fetcher: &ListFetcher{Options: []string{"one", "two"}},
+			args:    []string{"3", "basic", "un", "t"},
+			wantStdout: []string{
+				"two",
+			},
+			want: []string{
+				`completing ["basic" "un" "t"] with cursor index 3`,
+				`matched subcommand "basic"`,
+				`arg "val_1" consumed ["un"]`,
+				`completing arg "variable 2" with "t"`,
+				`running fetcher *commands.ListFetcher`,
+				`fetcher returned suggestions ["one" "two"]`,
+				`returning suggestions ["two"]`,
+			},
+		},
+		{
+			name:    "traces fetcher errors",
+			fetcher: &errorFetcher{},
+			args:    []string{"2", "basic", ""},
+			wantErr: "failed to fetch suggestions: oops",
+			want: []string{
+				`completing ["basic" ""] with cursor index 2`,
+				`matched subcommand "basic"`,
+				`completing arg "val_1" with ""`,
+				`running fetcher *commands.errorFetcher`,
+				`fetcher failed: oops`,
+				`completion failed: failed to fetch suggestions: oops`,
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "trace_test")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+			traceFile := filepath.Join(dir, "trace.txt")
+
+			oldGetenv := getenv
+			getenv = func(key string) string {
+				if key == TraceFileEnv && !test.noTrace {
+					return traceFile
+				}
+				return ""
+			}
+			defer func() { getenv = oldGetenv }()
+
+			tcos := &TestCommandOS{}
+			err = RunAutocomplete(tcos, branchCommand(NoopExecutor, &Completor{SuggestionFetcher: test.fetcher}), test.args)
+			var gotErr string
+			if err != nil {
+				gotErr = err.Error()
+			}
+			if diff := cmp.Diff(test.wantErr, gotErr); diff != "" {
+				t.Errorf("RunAutocomplete(%v) returned error diff (-want, +got):\n%s", test.args, diff)
+			}
+			if diff := cmp.Diff(test.wantStdout, tcos.GetStdout()); diff != "" {
+				t.Errorf("RunAutocomplete(%v) produced stdout diff (-want, +got):\n%s", test.args, diff)
+			}
+			if diff := cmp.Diff([]string(nil), tcos.GetStderr()); diff != "" {
+				t.Errorf("RunAutocomplete(%v) produced stderr diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			var got []string
+			if b, err := ioutil.ReadFile(traceFile); err == nil {
+				got = strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("RunAutocomplete(%v) produced trace diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
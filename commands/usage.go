@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultUsageWidth is the width that usage text is wrapped to if the
+	// terminal width is unknown.
+	defaultUsageWidth = 80
+	// optionsToken replaces the optional flags in a synopsis.
+	optionsToken = "[OPTIONS]"
+	// maxUsageWidth is the width for usage text that shouldn't be wrapped.
+	maxUsageWidth = int(^uint(0) >> 1)
+)
+
+var (
+	// Used for testing.
+	terminalWidth = stdoutWidth
+)
+
+// usageWidth returns the width that usage text is wrapped to: the COLUMNS
+// environment variable, the width of the terminal that stdout is connected to
+// or defaultUsageWidth if stdout isn't a terminal.
+func usageWidth() int {
+	if w, err := strconv.Atoi(getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	if w, ok := terminalWidth(); ok && w > 0 {
+		return w
+	}
+	return defaultUsageWidth
+}
+
+// wrapTokens joins tokens into lines of at most width characters (unless a
+// single token is longer). The first line starts with prefix and the
+// following lines are indented by indent spaces. Tokens are separated by
+// spaces unless the previous token ends with "|" (see alternation).
+func wrapTokens(prefix string, tokens []string, indent, width int) []string {
+	var lines []string
+	line, empty := prefix, true
+	for _, t := range tokens {
+		sep := " "
+		if strings.TrimSpace(line) == "" || strings.HasSuffix(line, "|") {
+			sep = ""
+		}
+		if !empty && len(line)+len(sep)+len(t) > width {
+			lines = append(lines, line)
+			line, empty, sep = strings.Repeat(" ", indent), true, ""
+		}
+		line += sep + t
+		empty = false
+	}
+	return append(lines, line)
+}
+
+// usage returns the synopsis tokens of the argument.
+func (au *ArgUsage) usage() []string {
+	if au.Flag {
+		return []string{au.synopsis()}
+	}
+	return au.valueTokens()
+}
+
+// alternation returns the tokens of the subcommand names as alternatives
+// (e.g. "(add|" and "remove)"). Lines can only be wrapped between names.
+func (cu *CommandUsage) alternation() []string {
+	tokens := make([]string, 0, len(cu.Subcommands))
+	for i, sc := range cu.Subcommands {
+		t := sc.Name
+		if i == 0 {
+			t = "(" + t
+		}
+		if i == len(cu.Subcommands)-1 {
+			t += ")"
+		} else {
+			t += "|"
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+// synopses returns the tokens of each form of the command: one for selecting
+// a subcommand and one for the command's own args and flags. Optional flags
+// are replaced by optionsToken.
+func (cu *CommandUsage) synopses() [][]string {
+	var synopses [][]string
+	if len(cu.Subcommands) > 0 {
+		synopses = append(synopses, append(cu.alternation(), "..."))
+	}
+	if len(cu.Args) == 0 && len(cu.Flags) == 0 && len(synopses) > 0 {
+		return synopses
+	}
+
+	var tokens []string
+	for _, a := range cu.Args {
+		tokens = append(tokens, a.usage()...)
+	}
+	for _, f := range cu.Flags {
+		if f.Required {
+			tokens = append(tokens, f.usage()...)
+		}
+	}
+	if len(cu.optionTokens()) > 0 {
+		tokens = append(tokens, optionsToken)
+	}
+	return append(synopses, tokens)
+}
+
+// optionTokens returns the synopsis tokens of the optional flags.
+func (cu *CommandUsage) optionTokens() []string {
+	var tokens []string
+	for _, f := range cu.Flags {
+		if !f.Required {
+			tokens = append(tokens, f.usage()...)
+		}
+	}
+	return tokens
+}
+
+// synopsis returns the unwrapped synopsis lines for the command at the given
+// path.
+func (cu *CommandUsage) synopsis(path []string) []string {
+	var lines []string
+	prefix := strings.Join(path, " ")
+	for _, tokens := range cu.synopses() {
+		lines = append(lines, wrapTokens(prefix, tokens, 0, maxUsageWidth)[0])
+	}
+	return lines
+}
+
+// usageTree returns the usage lines of the command with the given name and
+// of its subcommands (indented by two more spaces).
+func (cu *CommandUsage) usageTree(name string, depth, width int) []string {
+	indent := strings.Repeat("  ", depth)
+	prefix := indent + name
+	tokenIndent := len(prefix) + 1
+	if name == "" {
+		tokenIndent = len(indent) + 1
+	}
+
+	var lines []string
+	for _, tokens := range cu.synopses() {
+		lines = append(lines, wrapTokens(prefix, tokens, tokenIndent, width)...)
+	}
+	if opts := cu.optionTokens(); len(opts) > 0 {
+		label := fmt.Sprintf("%s  Options:", indent)
+		lines = append(lines, wrapTokens(label, opts, len(label)+1, width)...)
+	}
+	label := fmt.Sprintf("%s  Constraints:", indent)
+	for i, c := range cu.Constraints {
+		prefix := label
+		if i > 0 {
+			prefix = strings.Repeat(" ", len(label)+1)
+		}
+		lines = append(lines, wrapTokens(prefix, strings.Fields(c), len(label)+1, width)...)
+	}
+	for _, sc := range cu.Subcommands {
+		lines = append(lines, sc.Usage.usageTree(sc.Name, depth+1, width)...)
+	}
+	return lines
+}
+
+// UsageText returns the usage lines of a command. The first lines are the
+// synopses of the command (e.g. "(add|remove) ..." for a CommandBranch),
+// followed by its options, its flag constraints (one per line) and the usage
+// of each subcommand, indented by two spaces. Lines are wrapped to the
+// terminal width (the COLUMNS environment variable, the width of the terminal
+// that stdout is connected to or 80 characters).
+func UsageText(cu *CommandUsage) []string {
+	return cu.usageTree("", 0, usageWidth())
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func init() {
+	// Usage text in tests shouldn't depend on the terminal they're run in.
+	terminalWidth = func() (int, bool) { return 0, false }
+}
+
+func TestWrapTokens(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		prefix string
+		tokens []string
+		indent int
+		width  int
+		want   []string
+	}{
+		{
+			name:   "fits on one line",
+			prefix: "cmd",
+			tokens: []string{"ARG", "[OPTIONS]"},
+			indent: 4,
+			width:  80,
+			want:   []string{"cmd ARG [OPTIONS]"},
+		},
+		{
+			name:   "wraps at width",
+			prefix: "cmd",
+			tokens: []string{"ONE", "TWO", "THREE"},
+			indent: 4,
+			width:  11,
+			want: []string{
+				"cmd ONE TWO",
+				"    THREE",
+			},
+		},
+		{
+			name:   "glues tokens after separator",
+			prefix: "cmd",
+			tokens: []string{"(add|", "delete|", "remove)", "..."},
+			indent: 4,
+			width:  16,
+			want: []string{
+				"cmd (add|delete|",
+				"    remove) ...",
+			},
+		},
+		{
+			name:   "long token gets its own line",
+			prefix: "cmd",
+			tokens: []string{"A", "VERY_LONG_TOKEN", "B"},
+			indent: 2,
+			width:  8,
+			want: []string{
+				"cmd A",
+				"  VERY_LONG_TOKEN",
+				"  B",
+			},
+		},
+		{
+			name:   "blank prefix",
+			prefix: "",
+			tokens: []string{"ONE", "TWO"},
+			indent: 1,
+			width:  80,
+			want:   []string{"ONE TWO"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := wrapTokens(test.prefix, test.tokens, test.indent, test.width)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("wrapTokens(%q, %v, %d, %d) returned diff (-want, +got):\n%s", test.prefix, test.tokens, test.indent, test.width, diff)
+			}
+		})
+	}
+}
+
+func TestUsageWidth(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		columns  string
+		terminal int
+		want     int
+	}{
+		{
+			name: "defaults without COLUMNS or terminal",
+			want: defaultUsageWidth,
+		},
+		{
+			name:    "uses COLUMNS",
+			columns: "120",
+			want:    120,
+		},
+		{
+			name:    "ignores invalid COLUMNS",
+			columns: "wide",
+			want:    defaultUsageWidth,
+		},
+		{
+			name:    "ignores non-positive COLUMNS",
+			columns: "0",
+			want:    defaultUsageWidth,
+		},
+		{
+			name:     "uses terminal width",
+			terminal: 100,
+			want:     100,
+		},
+		{
+			name:     "COLUMNS overrides terminal width",
+			columns:  "120",
+			terminal: 100,
+			want:     120,
+		},
+		{
+			name:     "ignores invalid COLUMNS with terminal",
+			columns:  "wide",
+			terminal: 100,
+			want:     100,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			oldGetenv := getenv
+			getenv = func(key string) string {
+				if key == "COLUMNS" {
+					return test.columns
+				}
+				return ""
+			}
+			defer func() { getenv = oldGetenv }()
+
+			oldTerminalWidth := terminalWidth
+			terminalWidth = func() (int, bool) { return test.terminal, test.terminal != 0 }
+			defer func() { terminalWidth = oldTerminalWidth }()
+
+			if got := usageWidth(); got != test.want {
+				t.Errorf("usageWidth() returned %d; want %d", got, test.want)
+			}
+		})
+	}
+}
@@ -87,7 +87,7 @@ func TestValueCommands(t *testing.T) {
 				Args: []Arg{
 					test.argDef,
 				},
-				Executor: func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, bool) {
+				Executor: func(cos CommandOS, args, flags map[string]*Value, _ *OptionInfo) (*ExecutorResponse, error) {
 					v := args[test.argDef.Name()]
 
 					// strings
@@ -119,14 +119,14 @@ func TestValueCommands(t *testing.T) {
 						t.Errorf("Bool() produced diff (-want, +got):\n%s", diff)
 					}
 
-					return &ExecutorResponse{}, true
+					return &ExecutorResponse{}, nil
 				},
 			}
 
 			tcos := &TestCommandOS{}
-			got, ok := Execute(tcos, cmd, test.args, nil)
+			got, err := Execute(tcos, cmd, test.args, nil)
 
-			if ok != test.wantOK {
+			if ok := err == nil; ok != test.wantOK {
 				t.Fatalf("commands.Execute(%v) returned %v for ok; want %v", test.args, ok, test.wantOK)
 			}
 
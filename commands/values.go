@@ -65,6 +65,7 @@ func FloatValue(f float64) *Value {
 type Value struct {
 	type_    ValueType
 	provided bool
+	source   ValueSource
 
 	string     *string
 	int        *int
@@ -199,10 +200,73 @@ func (v *Value) UnmarshalJSON(b []byte) error {
 	return err
 }
 
+// Provided returns whether the value was provided (on the command line, by an
+// environment variable or by the config file). A bool flag that is explicitly
+// set to false (e.g. with "--no-<name>") is provided.
 func (v *Value) Provided() bool {
 	return v != nil && v.provided
 }
 
+// appendList returns a list value with the elements of v followed by the
+// elements of that. that is returned if v isn't a list value.
+func (v *Value) appendList(that *Value) *Value {
+	switch v.type_ {
+	case StringListType:
+		return StringListValue(append(cp(v.stringList), that.stringList...)...)
+	case IntListType:
+		return IntListValue(append(append([]int{}, v.intList...), that.intList...)...)
+	case FloatListType:
+		return FloatListValue(append(append([]float64{}, v.floatList...), that.floatList...)...)
+	}
+	return that
+}
+
+// ValueSource is where the value of an argument came from.
+type ValueSource int
+
+const (
+	// CommandLineSource is the source of values provided as arguments.
+	CommandLineSource ValueSource = iota
+	// EnvSource is the source of values set by an environment variable (see
+	// EnvVar).
+	EnvSource
+	// ConfigSource is the source of values set by the CLI's config file (see
+	// ConfigKey).
+	ConfigSource
+	// DefaultSource is the source of default values (see Default).
+	DefaultSource
+)
+
+var (
+	sourceToString = map[ValueSource]string{
+		CommandLineSource: "command line",
+		EnvSource:         "environment",
+		ConfigSource:      "config",
+		DefaultSource:     "default",
+	}
+)
+
+func (vs ValueSource) String() string {
+	return sourceToString[vs]
+}
+
+// Source returns where the value came from.
+func (v *Value) Source() ValueSource {
+	if v == nil {
+		return CommandLineSource
+	}
+	return v.source
+}
+
+// unprovided returns a copy of v with the DefaultSource that isn't marked as
+// provided.
+func (v *Value) unprovided() *Value {
+	c := *v
+	c.provided = false
+	c.source = DefaultSource
+	return &c
+}
+
 func (v *Value) String() string {
 	if v == nil || v.string == nil {
 		return ""
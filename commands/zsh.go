@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ZshCompletion returns a zsh script that sets up autocompletion for cli. It
+// can be sourced from a .zshrc file (after compinit has been run).
+//
+// The generated function fetches suggestions by running
+//
+//	binary autocomplete-zsh CLI CURSOR_IDX WORDS...
+//
+// where WORDS are the words of the command line (excluding the CLI name) up
+// to the cursor. binary should pass everything after the CLI name to
+// RunZshAutocomplete.
+func ZshCompletion(binary, cli string) string {
+	fn := functionName(cli, "completion")
+	return strings.Join([]string{
+		fmt.Sprintf("# Autocompletion for %s.", cli),
+		fmt.Sprintf("function %s {", fn),
+		"  local -a output suggestions nospace",
+		"  local line section=suggestions",
+		fmt.Sprintf(`  output=( "${(@f)$(%s autocomplete-zsh %s $((CURRENT - 1)) "${(@)words[2,CURRENT]}")}" )`, quoteBash(binary), quoteBash(cli)),
+		`  for line in "${(@)output[2,-1]}"; do`,
+		`    if [[ -z "$line" ]]; then`,
+		"      section=nospace",
+		`    elif [[ "$section" == nospace ]]; then`,
+		`      nospace+=( "$line" )`,
+		"    else",
+		`      suggestions+=( "$line" )`,
+		"    fi",
+		"  done",
+		`  _describe -t suggestions 'suggestions' suggestions -p "${output[1]}"`,
+		`  _describe -t suggestions 'suggestions' nospace -p "${output[1]}" -S ''`,
+		"}",
+		fmt.Sprintf("compdef %s %s", fn, quoteBash(cli)),
+		"",
+	}, "\n")
+}
+
+// zshDescribe formats a suggestion as a value:description pair for _describe.
+func zshDescribe(suggestion, description string) string {
+	value := strings.ReplaceAll(suggestion, ":", `\:`)
+	if description == "" {
+		return value
+	}
+	return fmt.Sprintf("%s:%s", value, description)
+}
+
+// ZshAutocomplete completes the given unparsed command for the script
+// generated by ZshCompletion.
+//
+// The first line of the response is the Completion's Prefix. It is followed
+// by a value:description pair for each suggestion. Suggestions that shouldn't
+// be followed by a space (directories and the partial completions that
+// FileFetcher marks with a suffixed duplicate) come last, after an empty line.
+// Unlike Autocomplete, values aren't escaped because zsh quotes them itself.
+func ZshAutocomplete(c Command, unparsedArgs []string, cursorIdx int) ([]string, error) {
+	completion, _, err := complete(c, unparsedArgs, cursorIdx)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestionSet := map[string]bool{}
+	for _, s := range completion.Suggestions {
+		suggestionSet[s] = true
+	}
+
+	var spaced, unspaced []string
+	for _, s := range completion.Suggestions {
+		suffixed := !completion.DontComplete && suggestionSet[s+suffixChar]
+		if !completion.DontComplete && strings.HasSuffix(s, suffixChar) && suggestionSet[strings.TrimSuffix(s, suffixChar)] {
+			continue
+		}
+
+		pair := zshDescribe(s, completion.Descriptions[s])
+		if suffixed || strings.HasSuffix(s, "/") {
+			unspaced = append(unspaced, pair)
+		} else {
+			spaced = append(spaced, pair)
+		}
+	}
+
+	r := append([]string{completion.Prefix}, spaced...)
+	if len(unspaced) > 0 {
+		r = append(r, "")
+		r = append(r, unspaced...)
+	}
+	return r, nil
+}
+
+// RunZshAutocomplete handles a completion request from the script generated
+// by ZshCompletion. args are the cursor index followed by the words of the
+// command line (excluding the CLI name).
+func RunZshAutocomplete(cos CommandOS, c Command, args []string) error {
+	return runAutocomplete(cos, c, args, ZshAutocomplete)
+}
@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestZshCompletion(t *testing.T) {
+	want := strings.Join([]string{
+		"# Autocompletion for my-cli.",
+		"function _my_cli_completion {",
+		"  local -a output suggestions nospace",
+		"  local line section=suggestions",
+		`  output=( "${(@f)$('/usr/bin/binary' autocomplete-zsh 'my-cli' $((CURRENT - 1)) "${(@)words[2,CURRENT]}")}" )`,
+		`  for line in "${(@)output[2,-1]}"; do`,
+		`    if [[ -z "$line" ]]; then`,
+		"      section=nospace",
+		`    elif [[ "$section" == nospace ]]; then`,
+		`      nospace+=( "$line" )`,
+		"    else",
+		`      suggestions+=( "$line" )`,
+		"    fi",
+		"  done",
+		`  _describe -t suggestions 'suggestions' suggestions -p "${output[1]}"`,
+		`  _describe -t suggestions 'suggestions' nospace -p "${output[1]}" -S ''`,
+		"}",
+		"compdef _my_cli_completion 'my-cli'",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, ZshCompletion("/usr/bin/binary", "my-cli")); diff != "" {
+		t.Errorf("ZshCompletion() returned diff (-want, +got):\n%s", diff)
+	}
+}
+
+type completionFetcher struct {
+	completion *Completion
+}
+
+func (cf *completionFetcher) Fetch(_ *Value, _, _ map[string]*Value) (*Completion, error) {
+	return cf.completion, nil
+}
+
+func TestZshAutocomplete(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		fetcher Fetcher
+		args    []string
+		want    []string
+	}{
+		{
+			name: "returns empty prefix for no suggestions",
+			fetcher: &ListFetcher{
+				Options: []string{"one", "two"},
+			},
+			args: []string{"three"},
+			want: []string{""},
+		},
+		{
+			name: "returns suggestions",
+			fetcher: &ListFetcher{
+				Options: []string{"one", "two", "three"},
+			},
+			args: []string{"t"},
+			want: []string{"", "three", "two"},
+		},
+		{
+			name: "doesn't escape spaces",
+			fetcher: &ListFetcher{
+				Options: []string{"with space", "without"},
+			},
+			args: []string{"w"},
+			want: []string{"", "with space", "without"},
+		},
+		{
+			name: "returns descriptions and escapes colons",
+			fetcher: &completionFetcher{
+				completion: &Completion{
+					Suggestions: []string{"a:b", "cd", "ef"},
+					Descriptions: map[string]string{
+						"a:b": "first: one",
+						"ef":  "third",
+					},
+				},
+			},
+			want: []string{"", `a\:b:first: one`, "cd", "ef:third"},
+		},
+		{
+			name: "returns list fetcher descriptions",
+			fetcher: &ListFetcher{
+				Options: []string{"one", "two"},
+				Descriptions: map[string]string{
+					"two": "second",
+				},
+			},
+			args: []string{""},
+			want: []string{"", "one", "two:second"},
+		},
+		{
+			name:    "returns file basenames with prefix",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/dir1/"},
+			want:    []string{"testing/dir1/", "first.txt", "fourth.py", "second.py", "third.go"},
+		},
+		{
+			name:    "returns directories without a space",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/dir"},
+			want:    []string{"testing/", "", "dir1/", "dir2/", "dir3/", "dir4/"},
+		},
+		{
+			name:    "returns single directory without a space",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/dir1"},
+			want:    []string{"", "", "testing/dir1/"},
+		},
+		{
+			name:    "returns partial completion without a space",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/d"},
+			want:    []string{"", "", "testing/dir"},
+		},
+		{
+			name:    "returns single file",
+			fetcher: &FileFetcher{},
+			args:    []string{"testing/dir1/fi"},
+			want:    []string{"", "testing/dir1/first.txt"},
+		},
+		{
+			name:    "returns unescaped file names",
+			fetcher: &FileFetcher{},
+			args:    []string{`testing/dir4/folder\ w`},
+			want:    []string{"", "", "testing/dir4/folder with spaces/"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &TerminusCommand{
+				Args: []Arg{
+					StringArg("test", true, &Completor{SuggestionFetcher: test.fetcher}),
+				},
+			}
+			got, err := ZshAutocomplete(cmd, test.args, 0)
+			if err != nil {
+				t.Fatalf("ZshAutocomplete(%v) returned error: %v", test.args, err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("ZshAutocomplete(%v) returned diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+func TestRunZshAutocomplete(t *testing.T) {
+	tcos := &TestCommandOS{}
+	if err := RunZshAutocomplete(tcos, branchCommand(NoopExecutor, &Completor{}), []string{"1", "b"}); err != nil {
+		t.Errorf("RunZshAutocomplete() returned error: %v", err)
+	}
+	want := []string{"", "basic", "basically", "beginner"}
+	if diff := cmp.Diff(want, tcos.GetStdout()); diff != "" {
+		t.Errorf("RunZshAutocomplete() produced stdout diff (-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,216 @@
+// Package runner dispatches command line invocations to a set of CLIs.
+package runner
+
+import (
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/leep-frog/commands/commands"
+)
+
+const (
+	// ExitSuccess is the exit code returned when a run succeeds.
+	ExitSuccess = 0
+	// ExitFailure is the exit code returned when the runner fails. Failed CLI
+	// executions return commands.ExitCode of the returned error.
+	ExitFailure = 1
+	// ExitUsage is the exit code returned when the runner itself is invoked
+	// incorrectly (e.g. with an unknown mode or CLI).
+	ExitUsage = 2
+
+	// ExecuteMode runs a CLI: execute CLI ARGS...
+	ExecuteMode = "execute"
+	// AutocompleteMode prints bash completions: autocomplete CLI CURSOR_IDX ARGS...
+	AutocompleteMode = "autocomplete"
+	// ZshAutocompleteMode prints zsh completions: autocomplete-zsh CLI CURSOR_IDX ARGS...
+	ZshAutocompleteMode = "autocomplete-zsh"
+	// FishAutocompleteMode prints fish completions: autocomplete-fish CLI CURSOR_IDX ARGS...
+	FishAutocompleteMode = "autocomplete-fish"
+	// UsageMode prints the help page of a CLI: usage CLI [ARGS...]
+	UsageMode = "usage"
+	// ManMode prints the roff man page of a CLI: man CLI
+	ManMode = "man"
+	// MarkdownMode prints the Markdown reference of a CLI: markdown CLI
+	MarkdownMode = "markdown"
+)
+
+var (
+	autocompleters = map[string]func(commands.CommandOS, commands.Command, []string) error{
+		AutocompleteMode:     commands.RunAutocomplete,
+		ZshAutocompleteMode:  commands.RunZshAutocomplete,
+		FishAutocompleteMode: commands.RunFishAutocomplete,
+	}
+)
+
+// CLI is a named command that can be run by a Runner.
+type CLI interface {
+	// Name is the name that the CLI is invoked with.
+	Name() string
+	// Command returns the CLI's command.
+	Command() commands.Command
+	// Option returns additional configuration for the CLI.
+	Option() *commands.Option
+}
+
+// Persistent is a CLI with state that is stored between runs (see
+// store.AliasStore). The state is loaded before the CLI is run and saved
+// after it is successfully executed.
+type Persistent interface {
+	Load() error
+	Save() error
+}
+
+type namedCLI struct {
+	name    string
+	command commands.Command
+	option  *commands.Option
+}
+
+func (nc *namedCLI) Name() string              { return nc.name }
+func (nc *namedCLI) Command() commands.Command { return nc.command }
+func (nc *namedCLI) Option() *commands.Option  { return nc.option }
+
+// NewCLI returns a CLI for the given name and command.
+func NewCLI(name string, c commands.Command, opt *commands.Option) CLI {
+	return &namedCLI{
+		name:    name,
+		command: c,
+		option:  opt,
+	}
+}
+
+// Runner runs CLIs according to the arguments it is invoked with. The first
+// argument is the mode (ExecuteMode, AutocompleteMode, ZshAutocompleteMode,
+// FishAutocompleteMode, UsageMode, ManMode or MarkdownMode) and the second is
+// the name of the CLI.
+type Runner struct {
+	CLIs []CLI
+	// ExecutableFile is the file that the Actions and Executable of an
+	// ExecutorResponse are written to (see commands.WriteResponse). If empty,
+	// executing a command that responds with either fails.
+	ExecutableFile string
+}
+
+// cliMap returns a map from CLI name to CLI.
+func (r *Runner) cliMap(cos commands.CommandOS) (map[string]CLI, bool) {
+	m := map[string]CLI{}
+	for _, cli := range r.CLIs {
+		if _, ok := m[cli.Name()]; ok {
+			cos.Stderr("multiple CLIs named %q", cli.Name())
+			return nil, false
+		}
+		m[cli.Name()] = cli
+	}
+	return m, true
+}
+
+// names returns the sorted names of all CLIs.
+func (r *Runner) names() []string {
+	names := make([]string, 0, len(r.CLIs))
+	for _, cli := range r.CLIs {
+		names = append(names, cli.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run runs the CLI specified by args and returns the exit code.
+func (r *Runner) Run(cos commands.CommandOS, args []string) int {
+	if len(args) < 2 {
+		cos.Stderr("usage: MODE CLI [ARGS...]")
+		return ExitUsage
+	}
+	mode, name, args := args[0], args[1], args[2:]
+
+	clis, ok := r.cliMap(cos)
+	if !ok {
+		return ExitUsage
+	}
+	cli, ok := clis[name]
+	if !ok {
+		cos.Stderr("unknown CLI %q; expected one of [%s]", name, strings.Join(r.names(), ", "))
+		return ExitUsage
+	}
+
+	p, persistent := cli.(Persistent)
+	if persistent {
+		if err := p.Load(); err != nil {
+			cos.Stderr("failed to load CLI state: %v", err)
+			return ExitFailure
+		}
+	}
+
+	if f, ok := autocompleters[mode]; ok {
+		if err := f(cos, cli.Command(), args); err != nil {
+			return commands.ExitCode(err)
+		}
+		return ExitSuccess
+	}
+
+	switch mode {
+	case ExecuteMode:
+		code := r.execute(cos, cli, args)
+		if code == ExitSuccess && persistent {
+			if err := p.Save(); err != nil {
+				cos.Stderr("failed to save CLI state: %v", err)
+				return ExitFailure
+			}
+		}
+		return code
+	case UsageMode:
+		commands.PrintHelp(cos, cli.Name(), cli.Command(), args)
+		return ExitSuccess
+	case ManMode:
+		for _, line := range commands.ManPage(cli.Name(), cli.Command()) {
+			cos.Stdout("%s", line)
+		}
+		return ExitSuccess
+	case MarkdownMode:
+		for _, line := range commands.MarkdownReference(cli.Name(), cli.Command()) {
+			cos.Stdout("%s", line)
+		}
+		return ExitSuccess
+	}
+	cos.Stderr("unknown mode %q", mode)
+	return ExitUsage
+}
+
+// execute runs the CLI's setup command and then executes the CLI with the
+// given args.
+func (r *Runner) execute(cos commands.CommandOS, cli CLI, args []string) int {
+	resp, err := commands.ExecuteWithOption(cos, cli.Name(), cli.Command(), args, cli.Option())
+	if err != nil {
+		return commands.ExitCode(err)
+	}
+
+	if resp == nil || (len(resp.Actions) == 0 && len(resp.Executable) == 0) {
+		return ExitSuccess
+	}
+
+	// The response can't be dropped silently since the caller expects its
+	// actions (e.g. a Chdir) to be run.
+	if r.ExecutableFile == "" {
+		cos.Stderr("can't run the response of %q since %s isn't set (is the CLI run through its bash wrapper?)", cli.Name(), commands.ExecutableFileEnv)
+		return ExitFailure
+	}
+
+	if err := commands.WriteResponse(r.ExecutableFile, resp); err != nil {
+		cos.Stderr("%v", err)
+		return ExitFailure
+	}
+	return ExitSuccess
+}
+
+// Main runs the CLI specified by the process's arguments and exits with the
+// resulting exit code. If ExecutableFile is empty, it's set from the
+// commands.ExecutableFileEnv environment variable (see commands.BashWrapper).
+func (r *Runner) Main() {
+	if r.ExecutableFile == "" {
+		r.ExecutableFile = os.Getenv(commands.ExecutableFileEnv)
+	}
+	cos := commands.NewCommandOS()
+	code := r.Run(cos, os.Args[1:])
+	cos.Close()
+	os.Exit(code)
+}
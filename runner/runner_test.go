@@ -0,0 +1,399 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/leep-frog/commands/commands"
+	"github.com/leep-frog/commands/store"
+)
+
+func testCLIs(resp *commands.ExecutorResponse) []CLI {
+	return []CLI{
+		NewCLI("greet", &commands.TerminusCommand{
+			Args: []commands.Arg{
+				commands.StringArg("name", true, &commands.Completor{
+					SuggestionFetcher: &commands.ListFetcher{
+						Options: []string{"world", "with space"},
+					},
+				}),
+			},
+			Executor: func(cos commands.CommandOS, args, _ map[string]*commands.Value, _ *commands.OptionInfo) (*commands.ExecutorResponse, error) {
+				cos.Stdout("hello %s", args["name"].String())
+				return resp, nil
+			},
+		}, nil),
+		NewCLI("setup", &commands.TerminusCommand{
+			Executor: func(cos commands.CommandOS, _, _ map[string]*commands.Value, oi *commands.OptionInfo) (*commands.ExecutorResponse, error) {
+				b, err := ioutil.ReadFile(oi.SetupOutputFile)
+				if err != nil {
+					return nil, fmt.Errorf("failed to read setup output: %v", err)
+				}
+				cos.Stdout("setup: %s", strings.TrimSpace(string(b)))
+				return nil, nil
+			},
+		}, &commands.Option{SetupCommand: "echo ready"}),
+		NewCLI("fail", &commands.TerminusCommand{
+			Executor: func(cos commands.CommandOS, _, _ map[string]*commands.Value, _ *commands.OptionInfo) (*commands.ExecutorResponse, error) {
+				return nil, fmt.Errorf("oops")
+			},
+		}, nil),
+	}
+}
+
+func TestRun(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		args           []string
+		clis           []CLI
+		resp           *commands.ExecutorResponse
+		want           int
+		wantStdout     []string
+		wantStderr     []string
+		wantExecutable string
+		// noExecutableFile is true if the runner is used without a bash wrapper.
+		noExecutableFile bool
+	}{
+		{
+			name:       "fails if no mode",
+			want:       ExitUsage,
+			wantStderr: []string{"usage: MODE CLI [ARGS...]"},
+		},
+		{
+			name:       "fails if no CLI",
+			args:       []string{"execute"},
+			want:       ExitUsage,
+			wantStderr: []string{"usage: MODE CLI [ARGS...]"},
+		},
+		{
+			name:       "fails if unknown CLI",
+			args:       []string{"execute", "wave"},
+			want:       ExitUsage,
+			wantStderr: []string{`unknown CLI "wave"; expected one of [fail, greet, setup]`},
+		},
+		{
+			name:       "fails if unknown mode",
+			args:       []string{"run", "greet"},
+			want:       ExitUsage,
+			wantStderr: []string{`unknown mode "run"`},
+		},
+		{
+			name: "fails if duplicate CLI names",
+			args: []string{"execute", "greet"},
+			clis: []CLI{
+				NewCLI("greet", &commands.TerminusCommand{}, nil),
+				NewCLI("greet", &commands.TerminusCommand{}, nil),
+			},
+			want:       ExitUsage,
+			wantStderr: []string{`multiple CLIs named "greet"`},
+		},
+		{
+			name:       "executes CLI",
+			args:       []string{"execute", "greet", "there"},
+			want:       ExitSuccess,
+			wantStdout: []string{"hello there"},
+		},
+		{
+			name:       "runs setup command",
+			args:       []string{"execute", "setup"},
+			want:       ExitSuccess,
+			wantStdout: []string{"setup: ready"},
+		},
+		{
+			name:       "returns failure if execution fails",
+			args:       []string{"execute", "fail"},
+			want:       ExitFailure,
+			wantStderr: []string{"oops"},
+		},
+		{
+			name:       "returns usage exit code if args are missing",
+			args:       []string{"execute", "greet"},
+			want:       commands.ExitCodeUsage,
+			wantStderr: []string{`no argument provided for "name"`},
+		},
+		{
+			name: "writes executable",
+			args: []string{"execute", "greet", "there"},
+			resp: &commands.ExecutorResponse{
+				Executable: []string{"cd", "it's here"},
+			},
+			want:           ExitSuccess,
+			wantStdout:     []string{"hello there"},
+			wantExecutable: "'cd' 'it'\\''s here'\n",
+		},
+		{
+			name: "writes actions",
+			args: []string{"execute", "greet", "there"},
+			resp: &commands.ExecutorResponse{
+				Actions: []commands.ShellAction{
+					&commands.Chdir{Dir: "here"},
+					&commands.SetEnv{Name: "GREETED", Value: "there"},
+				},
+				Executable: []string{"ls"},
+			},
+			want:           ExitSuccess,
+			wantStdout:     []string{"hello there"},
+			wantExecutable: "cd -- 'here'\nexport GREETED='there'\n'ls'\n",
+		},
+		{
+			name: "fails if actions can't be written",
+			args: []string{"execute", "greet", "there"},
+			resp: &commands.ExecutorResponse{
+				Actions: []commands.ShellAction{
+					&commands.Chdir{Dir: "here"},
+				},
+			},
+			noExecutableFile: true,
+			want:             ExitFailure,
+			wantStdout:       []string{"hello there"},
+			wantStderr:       []string{`can't run the response of "greet" since COMMANDS_EXECUTABLE_FILE isn't set (is the CLI run through its bash wrapper?)`},
+		},
+		{
+			name:             "executes CLI without executable file",
+			args:             []string{"execute", "greet", "there"},
+			noExecutableFile: true,
+			want:             ExitSuccess,
+			wantStdout:       []string{"hello there"},
+		},
+		{
+			name: "fails for invalid actions",
+			args: []string{"execute", "greet", "there"},
+			resp: &commands.ExecutorResponse{
+				Actions: []commands.ShellAction{&commands.Unset{}},
+			},
+			want:       ExitFailure,
+			wantStdout: []string{"hello there"},
+			wantStderr: []string{`failed to convert action to bash: invalid environment variable name ""`},
+		},
+		{
+			name:       "autocompletes for bash",
+			args:       []string{"autocomplete", "greet", "1", "w"},
+			want:       ExitSuccess,
+			wantStdout: []string{`with\ space`, "world"},
+		},
+		{
+			name:       "autocompletes for zsh",
+			args:       []string{"autocomplete-zsh", "greet", "1", "w"},
+			want:       ExitSuccess,
+			wantStdout: []string{"", "with space", "world"},
+		},
+		{
+			name:       "autocompletes for fish",
+			args:       []string{"autocomplete-fish", "greet", "1", "w"},
+			want:       ExitSuccess,
+			wantStdout: []string{"with space", "world"},
+		},
+		{
+			name:       "autocomplete fails without cursor index",
+			args:       []string{"autocomplete", "greet"},
+			want:       ExitUsage,
+			wantStderr: []string{"no cursor index provided"},
+		},
+		{
+			name: "prints help with CLI name",
+			args: []string{"execute", "greet", "--help"},
+			want: ExitSuccess,
+			wantStdout: []string{
+				"Usage: greet NAME",
+				"",
+				"Arguments:",
+				"  NAME  String",
+			},
+		},
+		{
+			name: "prints usage",
+			args: []string{"usage", "greet"},
+			want: ExitSuccess,
+			wantStdout: []string{
+				"Usage: greet NAME",
+				"",
+				"Arguments:",
+				"  NAME  String",
+			},
+		},
+		{
+			name: "prints man page",
+			args: []string{"man", "greet"},
+			want: ExitSuccess,
+			wantStdout: []string{
+				`.TH "GREET" "1"`,
+				".SH NAME",
+				"greet",
+				".SH SYNOPSIS",
+				".nf",
+				`\fBgreet\fR NAME`,
+				".fi",
+				".SH ARGUMENTS",
+				".TP",
+				`\fINAME\fR`,
+				"String",
+			},
+		},
+		{
+			name: "prints man page verbatim",
+			args: []string{"man", "sure"},
+			clis: []CLI{
+				NewCLI("sure", &commands.TerminusCommand{Description: "100% sure"}, nil),
+			},
+			want: ExitSuccess,
+			wantStdout: []string{
+				`.TH "SURE" "1"`,
+				".SH NAME",
+				`sure \- 100% sure`,
+				".SH SYNOPSIS",
+				".nf",
+				`\fBsure\fR`,
+				".fi",
+			},
+		},
+		{
+			name: "prints markdown reference",
+			args: []string{"markdown", "greet"},
+			want: ExitSuccess,
+			wantStdout: []string{
+				"# greet",
+				"",
+				"```",
+				"greet NAME",
+				"```",
+				"",
+				"**Arguments**",
+				"",
+				"| Name | Type | Required | Default | Validators | Description |",
+				"| --- | --- | --- | --- | --- | --- |",
+				"| `NAME` | String | yes |  |  |  |",
+			},
+		},
+		{
+			name: "prints markdown reference verbatim",
+			args: []string{"markdown", "sure"},
+			clis: []CLI{
+				NewCLI("sure", &commands.TerminusCommand{
+					Flags: []commands.Flag{
+						commands.BoolFlag("sure", 's', commands.Description("100% sure")),
+					},
+				}, nil),
+			},
+			want: ExitSuccess,
+			wantStdout: []string{
+				"# sure",
+				"",
+				"```",
+				"sure [OPTIONS]",
+				"```",
+				"",
+				"**Flags**",
+				"",
+				"| Name | Short name | Type | Required | Default | Validators | Description |",
+				"| --- | --- | --- | --- | --- | --- | --- |",
+				"| `--sure` | `-s` | Bool | no |  |  | 100% sure |",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "runner_test")
+			if err != nil {
+				t.Fatalf("failed to create temp dir: %v", err)
+			}
+			defer os.RemoveAll(dir)
+
+			clis := test.clis
+			if clis == nil {
+				clis = testCLIs(test.resp)
+			}
+			r := &Runner{
+				CLIs:           clis,
+				ExecutableFile: filepath.Join(dir, "executable"),
+			}
+			if test.noExecutableFile {
+				r.ExecutableFile = ""
+			}
+
+			tcos := &commands.TestCommandOS{}
+			if got := r.Run(tcos, test.args); got != test.want {
+				t.Errorf("Run(%v) returned %d; want %d", test.args, got, test.want)
+			}
+			if diff := cmp.Diff(test.wantStdout, tcos.GetStdout()); diff != "" {
+				t.Errorf("Run(%v) produced stdout diff (-want, +got):\n%s", test.args, diff)
+			}
+			if diff := cmp.Diff(test.wantStderr, tcos.GetStderr()); diff != "" {
+				t.Errorf("Run(%v) produced stderr diff (-want, +got):\n%s", test.args, diff)
+			}
+
+			var gotExecutable string
+			if b, err := ioutil.ReadFile(r.ExecutableFile); err == nil {
+				gotExecutable = string(b)
+			}
+			if diff := cmp.Diff(test.wantExecutable, gotExecutable); diff != "" {
+				t.Errorf("Run(%v) produced executable diff (-want, +got):\n%s", test.args, diff)
+			}
+		})
+	}
+}
+
+type aliasCLI struct {
+	*store.AliasStore
+}
+
+func (ac *aliasCLI) Name() string             { return "files" }
+func (ac *aliasCLI) Option() *commands.Option { return nil }
+func (ac *aliasCLI) Command() commands.Command {
+	return &commands.CommandBranch{
+		Subcommands: commands.AliasSubcommands(ac, commands.TestFileAliaser(
+			func(string) (os.FileInfo, error) { return nil, nil },
+			func(s string) (string, error) { return "/abs/" + s, nil },
+		), "files"),
+	}
+}
+
+func TestRunPersistent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(args ...string) []string {
+		r := &Runner{
+			CLIs: []CLI{&aliasCLI{store.NewAliasStore(dir, "files")}},
+		}
+		tcos := &commands.TestCommandOS{}
+		if got := r.Run(tcos, append([]string{"execute", "files"}, args...)); got != ExitSuccess {
+			t.Fatalf("Run(%v) returned %d; want %d: %v", args, got, ExitSuccess, tcos.GetStderr())
+		}
+		return tcos.GetStdout()
+	}
+
+	run("a", "f", "file.txt")
+	want := []string{"f: /abs/file.txt"}
+	if diff := cmp.Diff(want, run("g", "f")); diff != "" {
+		t.Errorf("Run() produced stdout diff after reload (-want, +got):\n%s", diff)
+	}
+}
+
+func TestRunPersistentLoadError(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	as := store.NewAliasStore(dir, "files")
+	if err := ioutil.WriteFile(filepath.Join(dir, "files.json"), []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to write state file: %v", err)
+	}
+	r := &Runner{CLIs: []CLI{&aliasCLI{as}}}
+	tcos := &commands.TestCommandOS{}
+	if got := r.Run(tcos, []string{"execute", "files", "l"}); got != ExitFailure {
+		t.Errorf("Run() returned %d; want %d", got, ExitFailure)
+	}
+	want := []string{"failed to load CLI state: failed to unmarshal state: unexpected end of JSON input"}
+	if diff := cmp.Diff(want, tcos.GetStderr()); diff != "" {
+		t.Errorf("Run() produced stderr diff (-want, +got):\n%s", diff)
+	}
+}
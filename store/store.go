@@ -0,0 +1,169 @@
+// Package store persists the state of CLIs in JSON files.
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/leep-frog/commands/commands"
+)
+
+const (
+	backupSuffix = ".bak"
+)
+
+// Store persists the state of a single CLI in a JSON file. The previous
+// version of the file is kept as a backup whenever the state is saved.
+type Store struct {
+	dir     string
+	name    string
+	changed bool
+}
+
+// New returns a Store for the CLI with the given name that keeps its files in
+// dir.
+func New(dir, name string) *Store {
+	return &Store{
+		dir:  dir,
+		name: name,
+	}
+}
+
+// Path returns the path of the file that the state is stored in.
+func (s *Store) Path() string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.json", s.name))
+}
+
+// BackupPath returns the path of the backup of the previous state.
+func (s *Store) BackupPath() string {
+	return s.Path() + backupSuffix
+}
+
+// MarkChanged specifies that the state has changed and should be saved.
+func (s *Store) MarkChanged() {
+	s.changed = true
+}
+
+// Changed returns whether the state has changed since it was last saved.
+func (s *Store) Changed() bool {
+	return s.changed
+}
+
+// Load unmarshals the stored state into v. v is left unchanged if no state
+// has been stored yet.
+func (s *Store) Load(v interface{}) error {
+	b, err := ioutil.ReadFile(s.Path())
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read state file: %v", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("failed to unmarshal state: %v", err)
+	}
+	return nil
+}
+
+// Save stores v if MarkChanged was called since the last save. The current
+// state file is copied to the backup file before being replaced.
+func (s *Store) Save(v interface{}) error {
+	if !s.changed {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %v", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %v", err)
+	}
+
+	prev, err := ioutil.ReadFile(s.Path())
+	if err == nil {
+		if err := s.writeAtomically(s.BackupPath(), prev); err != nil {
+			return fmt.Errorf("failed to write backup file: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read state file: %v", err)
+	}
+
+	if err := s.writeAtomically(s.Path(), b); err != nil {
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	s.changed = false
+	return nil
+}
+
+// writeAtomically writes b to a temporary file and then renames it to path so
+// that path never contains a partially written file.
+func (s *Store) writeAtomically(path string, b []byte) error {
+	f, err := ioutil.TempFile(s.dir, fmt.Sprintf(".%s.*.tmp", s.name))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// AliasStore is a commands.AliasCLI whose aliases are persisted in a Store.
+// CLIs that embed an AliasStore are loaded and saved by the runner package.
+type AliasStore struct {
+	store   *Store
+	aliases map[string]map[string]*commands.Value
+}
+
+// NewAliasStore returns an AliasStore for the CLI with the given name that
+// keeps its files in dir.
+func NewAliasStore(dir, name string) *AliasStore {
+	return &AliasStore{
+		store: New(dir, name),
+	}
+}
+
+// AliasMap returns a map from alias type to alias name to alias value.
+func (as *AliasStore) AliasMap() map[string]map[string]*commands.Value {
+	return as.aliases
+}
+
+// InitializeAliasMap initializes the alias map.
+func (as *AliasStore) InitializeAliasMap() {
+	as.aliases = map[string]map[string]*commands.Value{}
+}
+
+// MarkChanged specifies that the aliases have changed.
+func (as *AliasStore) MarkChanged() {
+	as.store.MarkChanged()
+}
+
+// Changed returns whether the aliases have changed since they were saved.
+func (as *AliasStore) Changed() bool {
+	return as.store.Changed()
+}
+
+// Load loads the stored aliases.
+func (as *AliasStore) Load() error {
+	return as.store.Load(&as.aliases)
+}
+
+// Save stores the aliases if they have changed.
+func (as *AliasStore) Save() error {
+	return as.store.Save(as.aliases)
+}
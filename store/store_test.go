@@ -0,0 +1,158 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/leep-frog/commands/commands"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "store_test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("failed to read %q: %v", path, err)
+	}
+	return string(b)
+}
+
+type state struct {
+	Count int
+	Value *commands.Value
+}
+
+func TestStore(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	s := New(filepath.Join(dir, "nested"), "cli")
+
+	// Loading without a state file leaves the value unchanged.
+	got := &state{Count: 3}
+	if err := s.Load(got); err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if diff := cmp.Diff(&state{Count: 3}, got, cmp.AllowUnexported(commands.Value{})); diff != "" {
+		t.Errorf("Load() produced diff (-want, +got):\n%s", diff)
+	}
+
+	// Nothing is saved if the state hasn't changed.
+	if err := s.Save(&state{Count: 4}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if got := readFile(t, s.Path()); got != "" {
+		t.Errorf("Save() wrote %q without changes; want nothing", got)
+	}
+
+	// State is saved once changed.
+	s.MarkChanged()
+	if !s.Changed() {
+		t.Errorf("Changed() returned false after MarkChanged()")
+	}
+	if err := s.Save(&state{Count: 5, Value: commands.StringListValue("a", "b")}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if s.Changed() {
+		t.Errorf("Changed() returned true after Save()")
+	}
+	first := readFile(t, s.Path())
+	if got := readFile(t, s.BackupPath()); got != "" {
+		t.Errorf("Save() wrote backup %q for the first save; want nothing", got)
+	}
+
+	got = &state{}
+	if err := s.Load(got); err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if diff := cmp.Diff(&state{Count: 5, Value: commands.StringListValue("a", "b")}, got, cmp.AllowUnexported(commands.Value{})); diff != "" {
+		t.Errorf("Load() produced diff (-want, +got):\n%s", diff)
+	}
+
+	// The previous version is backed up.
+	s.MarkChanged()
+	if err := s.Save(&state{Count: 6}); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+	if diff := cmp.Diff(first, readFile(t, s.BackupPath())); diff != "" {
+		t.Errorf("Save() produced backup diff (-want, +got):\n%s", diff)
+	}
+
+	// No temporary files are left behind.
+	files, err := ioutil.ReadDir(filepath.Join(dir, "nested"))
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	if diff := cmp.Diff([]string{"cli.json", "cli.json.bak"}, names); diff != "" {
+		t.Errorf("Save() produced files diff (-want, +got):\n%s", diff)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	s := New(dir, "cli")
+	if err := ioutil.WriteFile(s.Path(), []byte("{"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	want := "failed to unmarshal state: unexpected end of JSON input"
+	if err := s.Load(&state{}); err == nil || err.Error() != want {
+		t.Errorf("Load() returned error %v; want %q", err, want)
+	}
+}
+
+func TestAliasStore(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	as := NewAliasStore(dir, "aliases")
+	if err := as.Load(); err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	cmd := &commands.CommandBranch{
+		Subcommands: commands.AliasSubcommands(as, commands.TestFileAliaser(
+			func(string) (os.FileInfo, error) { return nil, nil },
+			func(s string) (string, error) { return "/abs/" + s, nil },
+		), "files"),
+	}
+
+	tcos := &commands.TestCommandOS{}
+	if _, err := commands.Execute(tcos, cmd, []string{"a", "f", "file.txt"}, nil); err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+	if !as.Changed() {
+		t.Errorf("Changed() returned false after adding alias")
+	}
+	if err := as.Save(); err != nil {
+		t.Fatalf("Save() returned error: %v", err)
+	}
+
+	loaded := NewAliasStore(dir, "aliases")
+	if err := loaded.Load(); err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	want := map[string]map[string]*commands.Value{
+		"files": {
+			"f": commands.StringValue("/abs/file.txt"),
+		},
+	}
+	if diff := cmp.Diff(want, loaded.AliasMap(), cmp.AllowUnexported(commands.Value{})); diff != "" {
+		t.Errorf("Load() produced aliases diff (-want, +got):\n%s", diff)
+	}
+}